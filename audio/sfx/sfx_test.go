@@ -11,7 +11,7 @@ import (
 func TestPlaySound(t *testing.T) {
 	r := run.TestRunner("..", "..")
 	fmt.Printf("TestRunner: %v", r)
-	drv := r.World().Audio
+	drv := r.Drivers.Audio
 	drv.TestMode()
 	// 22 kHz
 	test.Check(drv.Play("DSITMBK"), t)
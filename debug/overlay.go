@@ -0,0 +1,291 @@
+// Package debug builds overlays of the BSP, the blockmap and the collision data
+// of a map, drawn on top of the view to look at maps that misbehave.
+package debug
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tinogoehlert/goom/game"
+	"github.com/tinogoehlert/goom/level"
+	"github.com/tinogoehlert/goom/utils"
+)
+
+// Layer is a part of the overlay, layers are combined as flags.
+type Layer int
+
+const (
+	// LayerPartitions draws the partition lines of the nodes.
+	LayerPartitions Layer = 1 << iota
+	// LayerSubSectors draws the subsector polygons coloured by their index.
+	LayerSubSectors
+	// LayerPlayerSector draws the subsector and the sector under the player.
+	LayerPlayerSector
+	// LayerWallNormals draws the wall normals used by the wall collision.
+	LayerWallNormals
+	// LayerThingBoxes draws the boxes things are hit in.
+	LayerThingBoxes
+	// LayerProjectiles draws the paths of the projectiles.
+	LayerProjectiles
+	// LayerBlockMap draws the blockmap grid and the block under the player.
+	LayerBlockMap
+
+	// AllLayers draws everything.
+	AllLayers = LayerPartitions | LayerSubSectors | LayerPlayerSector | LayerWallNormals |
+		LayerThingBoxes | LayerProjectiles | LayerBlockMap
+)
+
+var layerNames = map[string]Layer{
+	"partitions":  LayerPartitions,
+	"subsectors":  LayerSubSectors,
+	"sector":      LayerPlayerSector,
+	"normals":     LayerWallNormals,
+	"things":      LayerThingBoxes,
+	"projectiles": LayerProjectiles,
+	"blockmap":    LayerBlockMap,
+	"all":         AllLayers,
+}
+
+// ParseLayers parses a comma separated list of layer names like "subsectors,things".
+func ParseLayers(names string) (Layer, error) {
+	var layers Layer
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		layer, ok := layerNames[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown debug layer %s", name)
+		}
+		layers |= layer
+	}
+	return layers, nil
+}
+
+// palette indices of the overlay colors
+const (
+	colorPartition   uint8 = 176 // red
+	colorSubSector   uint8 = 4   // white
+	colorSector      uint8 = 216 // orange
+	colorBlocking    uint8 = 112 // green
+	colorPassable    uint8 = 124 // dark green
+	colorMonster     uint8 = 168 // pink
+	colorThing       uint8 = 200 // blue
+	colorPlayer      uint8 = 4   // white
+	colorProjectile  uint8 = 224 // light yellow
+	colorProjPath    uint8 = 231 // dark yellow
+	colorBlockGrid   uint8 = 104 // gray
+	colorPlayerBlock uint8 = 227 // yellow
+)
+
+// subSectorColors are cycled through by the subsector index.
+var subSectorColors = []uint8{176, 112, 200, 231, 216, 250, 64, 168, 120, 192}
+
+const (
+	// defaultSpan map units shown from the top to the bottom of the frame
+	defaultSpan = 1024
+	minSpan     = 128
+	maxSpan     = 16384
+)
+
+// Line is a line of the overlay in map units with a palette index as color.
+type Line struct {
+	A, B  utils.Vec2
+	Color uint8
+}
+
+// Overlay holds the lines of the enabled layers, centered on the player.
+type Overlay struct {
+	Layers Layer
+	// Span map units shown from the top to the bottom of the frame
+	Span   float32
+	Center utils.Vec2
+	Lines  []Line
+	// SubSector and Sector under the player, -1 if unknown
+	SubSector, Sector int
+}
+
+// NewOverlay creates an overlay of the layers.
+func NewOverlay(layers Layer) *Overlay {
+	return &Overlay{Layers: layers, Span: defaultSpan, SubSector: -1, Sector: -1}
+}
+
+// Zoom scales the shown part of the map, factors above 1 zoom out.
+func (o *Overlay) Zoom(factor float32) {
+	o.Span = utils.Clamp(o.Span*factor, minSpan, maxSpan)
+}
+
+// View gets the view of the overlay on a frame of width x height pixels.
+func (o *Overlay) View(width, height int) View {
+	return View{
+		Center: o.Center,
+		Scale:  float32(height) / o.Span,
+		Width:  width,
+		Height: height,
+	}
+}
+
+// Info describes where the player is.
+func (o *Overlay) Info() string {
+	return fmt.Sprintf("subsector: %d sector: %d", o.SubSector, o.Sector)
+}
+
+// Build collects the lines of the world's current state.
+func (o *Overlay) Build(w *game.World) {
+	o.Lines = o.Lines[:0]
+	o.SubSector, o.Sector = -1, -1
+	lvl := w.GetLevel()
+	player := w.Me()
+	if lvl == nil || player == nil {
+		return
+	}
+	pos := player.Position()
+	o.Center = utils.V2(pos[0], pos[1])
+	if idx, err := lvl.SubSectorIndexAt(pos[0], pos[1]); err == nil {
+		o.SubSector = idx
+	}
+	sector := player.GetSector()
+	if sector != nil {
+		o.Sector = sector.Index()
+	}
+
+	if o.Layers&LayerBlockMap != 0 {
+		o.addBlockMap(lvl)
+	}
+	if o.Layers&LayerSubSectors != 0 {
+		for i := range lvl.BspSubSectors() {
+			o.addPolygon(lvl.SubSectorPolygon(i), subSectorColors[i%len(subSectorColors)])
+		}
+	}
+	if o.Layers&LayerPartitions != 0 {
+		nodes := lvl.BspNodes()
+		for i := range nodes {
+			start, end := nodes[i].Partition()
+			o.add(start, end, colorPartition)
+		}
+	}
+	if o.Layers&LayerPlayerSector != 0 {
+		o.addPlayerSector(lvl, sector)
+	}
+	if o.Layers&LayerWallNormals != 0 {
+		o.addWallNormals(lvl, sector)
+	}
+	if o.Layers&LayerThingBoxes != 0 {
+		o.addThingBoxes(w)
+	}
+	if o.Layers&LayerProjectiles != 0 {
+		for _, p := range w.Projectiles() {
+			var (
+				from = utils.V2(p.Origin()[0], p.Origin()[1])
+				at   = utils.V2(p.Position()[0], p.Position()[1])
+				// projectiles walk like movables, the map x is mirrored
+				dir = utils.V2(-p.Direction()[0], p.Direction()[1])
+			)
+			o.add(at, from.Add(dir.Scale(float32(p.MaxRange()))), colorProjPath)
+			o.add(from, at, colorProjectile)
+		}
+	}
+}
+
+func (o *Overlay) add(a, b utils.Vec2, color uint8) {
+	o.Lines = append(o.Lines, Line{A: a, B: b, Color: color})
+}
+
+func (o *Overlay) addPolygon(poly []utils.Vec2, color uint8) {
+	for i := range poly {
+		o.add(poly[i], poly[(i+1)%len(poly)], color)
+	}
+}
+
+// addBox adds a box of half size sx, sy around the center c.
+func (o *Overlay) addBox(c utils.Vec2, sx, sy float32, color uint8) {
+	o.addPolygon([]utils.Vec2{
+		utils.V2(c.X()-sx, c.Y()-sy),
+		utils.V2(c.X()+sx, c.Y()-sy),
+		utils.V2(c.X()+sx, c.Y()+sy),
+		utils.V2(c.X()-sx, c.Y()+sy),
+	}, color)
+}
+
+func (o *Overlay) addBlockMap(lvl *level.Level) {
+	bm := lvl.BlockMap
+	if bm == nil {
+		return
+	}
+	var (
+		width  = float32(bm.Columns * level.BlockSize)
+		height = float32(bm.Rows * level.BlockSize)
+	)
+	for col := 0; col <= bm.Columns; col++ {
+		x := bm.Origin.X() + float32(col*level.BlockSize)
+		o.add(utils.V2(x, bm.Origin.Y()), utils.V2(x, bm.Origin.Y()+height), colorBlockGrid)
+	}
+	for row := 0; row <= bm.Rows; row++ {
+		y := bm.Origin.Y() + float32(row*level.BlockSize)
+		o.add(utils.V2(bm.Origin.X(), y), utils.V2(bm.Origin.X()+width, y), colorBlockGrid)
+	}
+	col, row, ok := bm.BlockAt(o.Center.X(), o.Center.Y())
+	if !ok {
+		return
+	}
+	var (
+		half   = float32(level.BlockSize) / 2
+		center = bm.Origin.Add(utils.V2(float32(col*level.BlockSize)+half, float32(row*level.BlockSize)+half))
+	)
+	o.addBox(center, half, half, colorPlayerBlock)
+	for _, line := range bm.Block(col, row) {
+		if line < len(lvl.Walls) {
+			o.add(lvl.Walls[line].Start, lvl.Walls[line].End, colorPlayerBlock)
+		}
+	}
+}
+
+func (o *Overlay) addPlayerSector(lvl *level.Level, sector *level.Sector) {
+	if sector != nil {
+		for i := range lvl.Walls {
+			wall := &lvl.Walls[i]
+			if wall.Sectors.Right == sector || wall.Sectors.Left == sector {
+				o.add(wall.Start, wall.End, colorSector)
+			}
+		}
+	}
+	if o.SubSector >= 0 {
+		o.addPolygon(lvl.SubSectorPolygon(o.SubSector), colorSubSector)
+	}
+}
+
+// addWallNormals adds the normals of the walls as long as the collision radius,
+// walls blocking the player are bright.
+func (o *Overlay) addWallNormals(lvl *level.Level, sector *level.Sector) {
+	for i := range lvl.Walls {
+		var (
+			wall  = &lvl.Walls[i]
+			mid   = wall.Start.Add(wall.End).Scale(0.5)
+			color = colorBlocking
+		)
+		if sector != nil && !game.BlocksMove(wall, sector) {
+			color = colorPassable
+		}
+		o.add(wall.Start, wall.End, color)
+		o.add(mid, mid.Add(wall.Normal.Scale(game.CollisionRadius)), color)
+	}
+}
+
+func (o *Overlay) addThingBoxes(w *game.World) {
+	for _, t := range w.Things() {
+		if !t.IsShown() {
+			continue
+		}
+		var (
+			pos    = utils.V2(t.Position()[0], t.Position()[1])
+			sx, sy = game.HitBox(t)
+			color  = colorThing
+		)
+		if _, ok := t.(*game.Monster); ok {
+			color = colorMonster
+		}
+		o.addBox(pos, sx, sy, color)
+	}
+	o.addBox(o.Center, game.CollisionRadius, game.CollisionRadius, colorPlayer)
+}
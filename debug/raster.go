@@ -0,0 +1,83 @@
+package debug
+
+import (
+	"math"
+
+	"github.com/tinogoehlert/goom/utils"
+)
+
+// View maps the map units of the overlay onto the pixels of a frame, north is up.
+type View struct {
+	Center utils.Vec2
+	// Scale pixels per map unit
+	Scale         float32
+	Width, Height int
+}
+
+// Project gets the pixel position of a point on the map.
+func (v View) Project(p utils.Vec2) (float32, float32) {
+	return float32(v.Width)/2 + (p.X()-v.Center.X())*v.Scale,
+		float32(v.Height)/2 - (p.Y()-v.Center.Y())*v.Scale
+}
+
+// DrawLines draws the lines clipped to the frame, plot sets a pixel.
+func (v View) DrawLines(lines []Line, plot func(x, y int, color uint8)) {
+	for _, l := range lines {
+		x0, y0 := v.Project(l.A)
+		x1, y1 := v.Project(l.B)
+		x0, y0, x1, y1, ok := clipLine(x0, y0, x1, y1, float32(v.Width-1), float32(v.Height-1))
+		if !ok {
+			continue
+		}
+		var (
+			dx    = x1 - x0
+			dy    = y1 - y0
+			steps = int(math.Ceil(float64(utils.Max(abs(dx), abs(dy)))))
+		)
+		if steps == 0 {
+			plot(int(x0+0.5), int(y0+0.5), l.Color)
+			continue
+		}
+		for i := 0; i <= steps; i++ {
+			t := float32(i) / float32(steps)
+			plot(int(x0+dx*t+0.5), int(y0+dy*t+0.5), l.Color)
+		}
+	}
+}
+
+// clipLine clips a line to the rectangle from 0, 0 to maxX, maxY like Liang-Barsky,
+// ok is false if the line is outside.
+func clipLine(x0, y0, x1, y1, maxX, maxY float32) (float32, float32, float32, float32, bool) {
+	var (
+		dx, dy   = x1 - x0, y1 - y0
+		t0, t1   = float32(0), float32(1)
+		edges    = [4]float32{-dx, dx, -dy, dy}
+		distance = [4]float32{x0, maxX - x0, y0, maxY - y0}
+	)
+	for i := range edges {
+		p, q := edges[i], distance[i]
+		if p == 0 {
+			if q < 0 {
+				return 0, 0, 0, 0, false
+			}
+			continue
+		}
+		r := q / p
+		if p < 0 {
+			t0 = utils.Max(t0, r)
+		} else {
+			t1 = utils.Min(t1, r)
+		}
+	}
+	if t0 > t1 {
+		return 0, 0, 0, 0, false
+	}
+	return x0 + dx*t0, y0 + dy*t0, x0 + dx*t1, y0 + dy*t1, true
+}
+
+func abs(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
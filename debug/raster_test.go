@@ -0,0 +1,42 @@
+package debug
+
+import (
+	"testing"
+
+	"github.com/tinogoehlert/goom/test"
+	"github.com/tinogoehlert/goom/utils"
+)
+
+func TestDrawLines(t *testing.T) {
+	var (
+		v      = View{Center: utils.V2(100, 100), Scale: 1, Width: 20, Height: 10}
+		pixels = map[[2]int]uint8{}
+	)
+	plot := func(x, y int, color uint8) {
+		test.Assert(x >= 0 && x < v.Width && y >= 0 && y < v.Height, "pixel outside of the frame", t)
+		pixels[[2]int{x, y}] = color
+	}
+	x, y := v.Project(utils.V2(100, 102))
+	test.Assert(x == 10 && y == 3, "expected north up", t)
+
+	// a horizontal line through the center, much longer than the frame
+	v.DrawLines([]Line{{A: utils.V2(-1000, 100), B: utils.V2(1000, 100), Color: 7}}, plot)
+	test.Assert(len(pixels) == 20, "expected the line clipped to the frame width", t)
+	test.Assert(pixels[[2]int{0, 5}] == 7 && pixels[[2]int{19, 5}] == 7, "expected the row of the center", t)
+
+	// a line left of the frame
+	pixels = map[[2]int]uint8{}
+	v.DrawLines([]Line{{A: utils.V2(0, 0), B: utils.V2(0, 200), Color: 7}}, plot)
+	test.Assert(len(pixels) == 0, "expected nothing drawn", t)
+}
+
+func TestParseLayers(t *testing.T) {
+	layers, err := ParseLayers("subsectors, Things")
+	test.Check(err, t)
+	test.Assert(layers == LayerSubSectors|LayerThingBoxes, "expected subsectors and things", t)
+	layers, err = ParseLayers("all")
+	test.Check(err, t)
+	test.Assert(layers == AllLayers, "expected all layers", t)
+	_, err = ParseLayers("bsp,nope")
+	test.Assert(err != nil, "expected an unknown layer", t)
+}
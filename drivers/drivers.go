@@ -15,13 +15,17 @@ type AudioDriver string
 // MusicDriver defines a music driver by name.
 type MusicDriver string
 
+// RendererDriver defines a renderer by name.
+type RendererDriver string
+
 // setup driver maps to allow dynamic access to actual drivers
 var (
-	WindowDrivers = make(map[WindowDriver]Window)
-	AudioDrivers  = make(map[AudioDriver]Audio)
-	MusicDrivers  = make(map[MusicDriver]Music)
-	InputDrivers  = make(map[InputDriver]Input)
-	TimerFuncs    = make(map[Timer]TimerFunc)
+	WindowDrivers   = make(map[WindowDriver]Window)
+	AudioDrivers    = make(map[AudioDriver]Audio)
+	MusicDrivers    = make(map[MusicDriver]Music)
+	InputDrivers    = make(map[InputDriver]Input)
+	TimerFuncs      = make(map[Timer]TimerFunc)
+	RendererDrivers = make(map[RendererDriver]RendererFactory)
 )
 
 // Drivers stores the engine drivers.
@@ -35,9 +39,16 @@ type Drivers struct {
 
 // Define common and specific driver names by name.
 const (
-	Noop      = "noop"
-	NoopAudio = AudioDriver(Noop)
-	NoopMusic = MusicDriver(Noop)
+	Noop         = "noop"
+	NoopAudio    = AudioDriver(Noop)
+	NoopMusic    = MusicDriver(Noop)
+	NoopRenderer = RendererDriver(Noop)
+
+	OpenGL         = "opengl"
+	OpenGLRenderer = RendererDriver(OpenGL)
+
+	Software         = "software"
+	SoftwareRenderer = RendererDriver(Software)
 
 	Glfw       = "glfw"
 	GlfwWindow = WindowDriver(Glfw)
@@ -105,6 +105,11 @@ var glfwDriversKeyMap = map[shared.Keycode]glfw.Key{
 	shared.KeyF6:     glfw.KeyF6,
 	shared.KeyF7:     glfw.KeyF7,
 	shared.KeyF8:     glfw.KeyF8,
+	shared.KeyF9:     glfw.KeyF9,
+	shared.KeyF11:    glfw.KeyF11,
+	shared.KeyF12:    glfw.KeyF12,
+	shared.KeyMinus:  glfw.KeyMinus,
+	shared.KeyEquals: glfw.KeyEqual,
 }
 
 var glfwMouseButtonMap = map[shared.MouseButton]glfw.MouseButton{
@@ -18,6 +18,13 @@ type Window struct {
 	mouseCameraEnabled bool
 	lastMouseX         float64
 	lastMouseY         float64
+	offscreen          bool
+}
+
+// SetOffscreen creates the window hidden, its GL context renders into
+// offscreen framebuffers. It must be called before Open.
+func (w *Window) SetOffscreen(enabled bool) {
+	w.offscreen = enabled
 }
 
 // Open creates a new GLFW Window.
@@ -35,6 +42,10 @@ func (w *Window) Open(title string, width, height int) error {
 	glfw.WindowHint(glfw.ContextVersionMinor, 2)
 	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
 	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	glfw.WindowHint(glfw.Visible, glfw.True)
+	if w.offscreen {
+		glfw.WindowHint(glfw.Visible, glfw.False)
+	}
 
 	gw, err := glfw.CreateWindow(width, height, title, nil, nil)
 	if err != nil {
@@ -94,10 +105,8 @@ func (w *Window) RunGame(input func(), update func(), render func(float64)) {
 			update()
 		}
 
-		// TODO: This tells the renderer how close we are to the next tick, so if we
-		//       are between two ticks we can display (as an example) the movement
-		//       of a projectile by and additional 0.8 units instead of fixed 1 unit.
-		//       The todo is to implement this in the renderer ^^.
+		// how close we are to the next tick, the renderer interpolates
+		// the moving things and sectors between the last and the next tick
 		render(lag / w.secsPerUpdate)
 		w.window.SwapBuffers()
 	}
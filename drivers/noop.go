@@ -1,11 +1,19 @@
 package drivers
 
-import "github.com/tinogoehlert/goom/drivers/noop"
+import (
+	"github.com/tinogoehlert/goom/drivers/noop"
+	"github.com/tinogoehlert/goom/goom"
+)
 
 func init() {
 	noopAudio := &noop.Audio{}
 	AudioDrivers[NoopAudio] = noopAudio
 	MusicDrivers[NoopMusic] = noopAudio
+	RendererDrivers[NoopRenderer] = RendererFactory{
+		New: func(gd *goom.GameData, opts RendererOptions) (Renderer, error) {
+			return &noop.Renderer{Width: opts.Width, Height: opts.Height}, nil
+		},
+	}
 }
 
 // NoopDrivers returns all Noop drivers.
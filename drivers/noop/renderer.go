@@ -0,0 +1,133 @@
+package noop
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/tinogoehlert/goom/debug"
+	"github.com/tinogoehlert/goom/game"
+	"github.com/tinogoehlert/goom/goom"
+	"github.com/tinogoehlert/goom/level"
+)
+
+// Renderer draws nothing, it records the draw calls of the last frame
+// for headless runs and tests.
+type Renderer struct {
+	Level         *level.Level
+	Width, Height int
+	Position      [2]float32
+	Direction     [3]float32
+	EyeHeight     float32
+	LevelTime     int
+	Interpolation float32
+	Palette       int
+	Gamma         int
+	Invulnerable  bool
+	// Frames counts the started frames
+	Frames int
+	// SubSectors, Things, HUDElements and Graphics drawn in the last frame
+	SubSectors  []int
+	Things      int
+	HUDElements []string
+	Graphics    []string
+	// OverlayLines of the debug overlay drawn in the last frame
+	OverlayLines int
+}
+
+// LoadLevel records the level.
+func (r *Renderer) LoadLevel(m *level.Level, gd *goom.GameData) {
+	r.Level = m
+}
+
+// SetViewPort records the framebuffer size.
+func (r *Renderer) SetViewPort(width, height int) {
+	r.Width, r.Height = width, height
+}
+
+// SetCamera records the camera.
+func (r *Renderer) SetCamera(pos [2]float32, dir [3]float32, height float32) {
+	r.Position, r.Direction, r.EyeHeight = pos, dir, height
+}
+
+// Frustum is nil, nothing is culled.
+func (r *Renderer) Frustum() *level.Frustum {
+	return nil
+}
+
+// SetLevelTime records the tic.
+func (r *Renderer) SetLevelTime(tic int) {
+	r.LevelTime = tic
+}
+
+// SetInterpolation records the tic fraction.
+func (r *Renderer) SetInterpolation(frac float32) {
+	r.Interpolation = frac
+}
+
+// SetPalette records the palette.
+func (r *Renderer) SetPalette(index int) {
+	r.Palette = index
+}
+
+// SetGamma records the gamma level.
+func (r *Renderer) SetGamma(level int) {
+	r.Gamma = level
+}
+
+// SetInvulnerability records the invulnerability colormap.
+func (r *Renderer) SetInvulnerability(enabled bool) {
+	r.Invulnerable = enabled
+}
+
+// RenderNewFrame counts the frame and forgets the draw calls of the last one.
+func (r *Renderer) RenderNewFrame() {
+	r.Frames++
+	r.SubSectors = r.SubSectors[:0]
+	r.Things = 0
+	r.HUDElements = r.HUDElements[:0]
+	r.Graphics = r.Graphics[:0]
+	r.OverlayLines = 0
+}
+
+// DrawSubSector records the subsector.
+func (r *Renderer) DrawSubSector(idx int) {
+	r.SubSectors = append(r.SubSectors, idx)
+}
+
+// DrawThings counts the shown things.
+func (r *Renderer) DrawThings(things []game.Thingable) {
+	for _, t := range things {
+		if t.IsShown() {
+			r.Things++
+		}
+	}
+}
+
+// DrawHUD does nothing.
+func (r *Renderer) DrawHUD(player *game.Player, t float64) {}
+
+// DrawHUdElement records the element.
+func (r *Renderer) DrawHUdElement(name string, xpos, ypos float32, scaleFactor float32) {
+	r.HUDElements = append(r.HUDElements, name)
+}
+
+// DrawOverlay counts the lines of the debug overlay.
+func (r *Renderer) DrawOverlay(o *debug.Overlay) {
+	r.OverlayLines += len(o.Lines)
+}
+
+// DrawGraphic records the graphic.
+func (r *Renderer) DrawGraphic(name string, x, y float32) {
+	r.Graphics = append(r.Graphics, name)
+}
+
+// FinishFrame does nothing.
+func (r *Renderer) FinishFrame() {}
+
+// Screenshot gets a black frame of the viewport size.
+func (r *Renderer) Screenshot() (*image.RGBA, error) {
+	if r.Width <= 0 || r.Height <= 0 {
+		return nil, fmt.Errorf("could not take screenshot: no viewport")
+	}
+	return image.NewRGBA(image.Rect(0, 0, r.Width, r.Height)), nil
+}
@@ -0,0 +1,59 @@
+package noop
+
+import (
+	"testing"
+
+	"github.com/tinogoehlert/goom/debug"
+	"github.com/tinogoehlert/goom/game"
+	"github.com/tinogoehlert/goom/test"
+)
+
+func TestRecordDrawCalls(t *testing.T) {
+	r := &Renderer{}
+	r.RenderNewFrame()
+	r.DrawSubSector(3)
+	r.DrawSubSector(7)
+	r.DrawThings([]game.Thingable{
+		game.NewDoomThing(0, 0, 0, "BAR1", false),
+		game.NewDoomThing(64, 0, 0, "BAR1", false),
+	})
+	r.DrawHUdElement("STBAR", 0, 0, 1)
+	r.DrawGraphic("M_PAUSE", 0, 0)
+	r.DrawOverlay(&debug.Overlay{Lines: make([]debug.Line, 4)})
+
+	test.Assert(len(r.SubSectors) == 2 && r.SubSectors[0] == 3 && r.SubSectors[1] == 7,
+		"subsectors should be recorded in draw order", t)
+	test.Assert(r.Things == 2, "shown things should be counted", t)
+	test.Assert(len(r.HUDElements) == 1 && r.HUDElements[0] == "STBAR",
+		"hud elements should be recorded", t)
+	test.Assert(len(r.Graphics) == 1 && r.Graphics[0] == "M_PAUSE",
+		"graphics should be recorded", t)
+	test.Assert(r.OverlayLines == 4, "overlay lines should be counted", t)
+}
+
+func TestNewFrameResetsDrawCalls(t *testing.T) {
+	r := &Renderer{}
+	r.RenderNewFrame()
+	r.DrawSubSector(1)
+	r.DrawHUdElement("STBAR", 0, 0, 1)
+	r.DrawGraphic("M_PAUSE", 0, 0)
+	r.DrawOverlay(&debug.Overlay{Lines: make([]debug.Line, 2)})
+	r.RenderNewFrame()
+
+	test.Assert(r.Frames == 2, "frames should be counted", t)
+	test.Assert(len(r.SubSectors) == 0 && r.Things == 0 && len(r.HUDElements) == 0 &&
+		len(r.Graphics) == 0 && r.OverlayLines == 0,
+		"a new frame should forget the draw calls of the last one", t)
+}
+
+func TestScreenshotNeedsViewport(t *testing.T) {
+	r := &Renderer{}
+	_, err := r.Screenshot()
+	test.Assert(err != nil, "screenshot without a viewport should fail", t)
+
+	r.SetViewPort(320, 200)
+	img, err := r.Screenshot()
+	test.Check(err, t)
+	test.Assert(img.Rect.Dx() == 320 && img.Rect.Dy() == 200,
+		"screenshot should have the viewport size", t)
+}
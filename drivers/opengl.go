@@ -0,0 +1,44 @@
+package drivers
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/tinogoehlert/goom/drivers/opengl"
+	"github.com/tinogoehlert/goom/goom"
+)
+
+func init() {
+	RendererDrivers[OpenGLRenderer] = RendererFactory{New: newGLRenderer}
+}
+
+// newGLRenderer creates the GL renderer with the main shader program,
+// the window must have a current GL context.
+func newGLRenderer(gd *goom.GameData, opts RendererOptions) (Renderer, error) {
+	if err := opengl.Init(); err != nil {
+		return nil, err
+	}
+	gr, err := opengl.NewRenderer(gd, opengl.Options{
+		IndexedColor: opts.IndexedColor,
+		Width:        opts.InternalWidth,
+		Height:       opts.InternalHeight,
+	})
+	if err != nil {
+		return nil, err
+	}
+	shaderDir := opts.ShaderDir
+	if shaderDir == "" {
+		shaderDir = path.Join("resources", "shaders")
+	}
+	if err = gr.LoadShaderProgram(
+		"main",
+		path.Join(shaderDir, "main.vert"),
+		path.Join(shaderDir, "main.frag"),
+	); err != nil {
+		return nil, fmt.Errorf("failed to load shaders: %w", err)
+	}
+	if err = gr.SetShaderProgram("main"); err != nil {
+		return nil, fmt.Errorf("failed to init shaders: %w", err)
+	}
+	return gr, nil
+}
@@ -0,0 +1,90 @@
+package opengl
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/go-gl/gl/v2.1/gl"
+)
+
+// glFramebuffer is an offscreen framebuffer with color and depth,
+// frames are drawn into it at the internal resolution.
+type glFramebuffer struct {
+	fbo    uint32
+	color  uint32
+	depth  uint32
+	width  int
+	height int
+}
+
+func newGLFramebuffer(width, height int) (*glFramebuffer, error) {
+	fb := &glFramebuffer{width: width, height: height}
+	gl.GenFramebuffers(1, &fb.fbo)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, fb.fbo)
+
+	gl.GenTextures(1, &fb.color)
+	gl.BindTexture(gl.TEXTURE_2D, fb.color)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, int32(width), int32(height), 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, fb.color, 0)
+
+	gl.GenRenderbuffers(1, &fb.depth)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, fb.depth)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT24, int32(width), int32(height))
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, fb.depth)
+
+	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		fb.release()
+		return nil, fmt.Errorf("could not create %dx%d framebuffer: status 0x%x", width, height, status)
+	}
+	return fb, nil
+}
+
+// bind directs the following draw calls into the framebuffer.
+func (fb *glFramebuffer) bind() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, fb.fbo)
+	gl.Viewport(0, 0, int32(fb.width), int32(fb.height))
+}
+
+// blit scales the frame onto the window's framebuffer, pixels stay sharp.
+func (fb *glFramebuffer) blit(width, height int) {
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, fb.fbo)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, 0)
+	gl.BlitFramebuffer(
+		0, 0, int32(fb.width), int32(fb.height),
+		0, 0, int32(width), int32(height),
+		gl.COLOR_BUFFER_BIT, gl.NEAREST,
+	)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+}
+
+// read copies the frame into an image, GL rows start at the bottom.
+func (fb *glFramebuffer) read() *image.RGBA {
+	var (
+		img    = image.NewRGBA(image.Rect(0, 0, fb.width, fb.height))
+		pixels = make([]uint8, len(img.Pix))
+		stride = fb.width * 4
+	)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, fb.fbo)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadPixels(0, 0, int32(fb.width), int32(fb.height), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pixels))
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	for y := 0; y < fb.height; y++ {
+		copy(img.Pix[y*stride:(y+1)*stride], pixels[(fb.height-1-y)*stride:])
+	}
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+	return img
+}
+
+// release deletes the framebuffer and its attachments.
+func (fb *glFramebuffer) release() {
+	gl.DeleteFramebuffers(1, &fb.fbo)
+	gl.DeleteTextures(1, &fb.color)
+	gl.DeleteRenderbuffers(1, &fb.depth)
+}
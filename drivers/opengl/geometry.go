@@ -6,16 +6,28 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 
 	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/tinogoehlert/goom/drivers/pkg"
+	"github.com/tinogoehlert/goom/graphics"
+	"github.com/tinogoehlert/goom/level"
 )
 
 type glWorldGeometry struct {
 	data     []float32
 	vao      uint32
+	vbo      uint32
 	texture  []*glTexture
 	light    float32
 	position mgl32.Vec3
 	seqTime  time.Time
 	isSky    bool
+	// texName name of the flat or wall texture, used to look up animation frames
+	texName string
+	isFlat  bool
+	// side and part point to the texture of walls on the sidedef, switches change it
+	side *level.SideDef
+	part level.SidePart
+	// masked is the see-through wall drawn in the masked pass, nil for opaque geometry
+	masked *pkg.WallQuad
 }
 
 func addGlWorldutils(dst []*glWorldGeometry, src *glWorldGeometry) []*glWorldGeometry {
@@ -36,6 +48,25 @@ func newGlWorldutils(data []float32, light float32, texture []*glTexture) *glWor
 	return m
 }
 
+// frameTexture gets the texture of the animation frame shown at the game tic.
+func (m *glWorldGeometry) frameTexture(ts glTextureStore, anims *graphics.Animations, tic int) *glTexture {
+	base := m.texName
+	if m.side != nil {
+		base = m.side.Texture(m.part)
+	}
+	name := anims.Texture(base, tic)
+	if m.isFlat {
+		name = anims.Flat(m.texName, tic)
+	}
+	if tex, ok := ts[name]; ok && len(tex) > 0 && tex[0] != nil {
+		return tex[0]
+	}
+	if tex, ok := ts[base]; ok && len(tex) > 0 && tex[0] != nil {
+		return tex[0]
+	}
+	return m.texture[0]
+}
+
 func (m *glWorldGeometry) pos() mgl32.Vec3 {
 	return m.position
 }
@@ -58,14 +89,49 @@ func (m *glWorldGeometry) DrawWithTexture(method uint32, tex *glTexture) {
 	gl.DrawArrays(method, 0, int32(len(m.data)/5))
 }
 
+// reuseGeometry gets the i-th of the geometries with its vertices updated in place,
+// or a new geometry when there are not that many.
+func reuseGeometry(geometries []*glWorldGeometry, i int, data []float32, light float32, texture []*glTexture) *glWorldGeometry {
+	if i >= len(geometries) {
+		return newGlWorldutils(data, light, texture)
+	}
+	m := geometries[i]
+	m.setData(data)
+	m.light, m.texture = light, texture
+	return m
+}
+
+// releaseUnused deletes the buffers of the old geometries that were not reused.
+func releaseUnused(old, reused []*glWorldGeometry) {
+	for i := len(reused); i < len(old); i++ {
+		old[i].release()
+	}
+}
+
+// setData updates the vertices in place, the buffer is only reallocated if their count changed.
+func (m *glWorldGeometry) setData(data []float32) {
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
+	if len(data) == len(m.data) {
+		gl.BufferSubData(gl.ARRAY_BUFFER, 0, len(data)*4, gl.Ptr(data))
+	} else {
+		gl.BufferData(gl.ARRAY_BUFFER, len(data)*4, gl.Ptr(data), gl.DYNAMIC_DRAW)
+	}
+	m.data = data
+}
+
+// release deletes the buffers of the geometry.
+func (m *glWorldGeometry) release() {
+	gl.DeleteVertexArrays(1, &m.vao)
+	gl.DeleteBuffers(1, &m.vbo)
+}
+
 func (m *glWorldGeometry) generateGLBuffers() {
-	var fVBO uint32
 	gl.GenVertexArrays(1, &m.vao)
-	gl.GenBuffers(1, &fVBO)
+	gl.GenBuffers(1, &m.vbo)
 	// bind the Vertex Array Object first, then bind and set vertex buffer(s), and then configure vertex attributes(s).
 	gl.BindVertexArray(m.vao)
 
-	gl.BindBuffer(gl.ARRAY_BUFFER, fVBO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
 	gl.BufferData(gl.ARRAY_BUFFER, len(m.data)*4, gl.Ptr(m.data), gl.STATIC_DRAW)
 
 	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 5*4, gl.PtrOffset(0))
@@ -1,11 +1,12 @@
 package opengl
 
 import (
-	"fmt"
-
 	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/tinogoehlert/goom/drivers/pkg"
 	"github.com/tinogoehlert/goom/goom"
+	"github.com/tinogoehlert/goom/graphics"
 	"github.com/tinogoehlert/goom/level"
+	"github.com/tinogoehlert/goom/utils"
 )
 
 type doomLevel struct {
@@ -14,13 +15,22 @@ type doomLevel struct {
 	mapRef     *level.Level
 	skyName    string
 	sky        *glTexture
+	gd         *goom.GameData
+	textures   glTextureStore
+	// dependents subsectors of the sectors, dirty ones rebuild their geometry
+	dependents     map[int][]int
+	dirty          map[int]bool
+	removeListener func()
+	// moving sectors and the fraction of the tic their heights are interpolated with
+	moving map[int]bool
+	frac   float32
 }
 
 type subSector struct {
 	floors   []*glWorldGeometry
 	ceilings []*glWorldGeometry
 	walls    []*glWorldGeometry
-	sector   level.Sector
+	sector   *level.Sector
 	ref      level.SubSector
 }
 
@@ -28,58 +38,108 @@ func RegisterMap(m *level.Level, gd *goom.GameData, ts glTextureStore, skyName s
 	l := doomLevel{
 		name:       m.Name,
 		mapRef:     m,
-		subSectors: make([]*subSector, 0, len(m.SubSectors(level.GLNodesName))),
+		gd:         gd,
+		textures:   ts,
+		subSectors: make([]*subSector, 0, len(m.BspSubSectors())),
+		dependents: pkg.SectorSubSectors(m),
+		dirty:      make(map[int]bool),
+		moving:     make(map[int]bool),
+		frac:       1,
 	}
-	for _, ssect := range m.SubSectors(level.GLSsectsName) {
+	for i, ssect := range m.BspSubSectors() {
 		var s = &subSector{ref: ssect}
-		s.addFlats(m, gd, ts)
-		s.addWalls(m, gd, ts)
+		s.addFlats(m, m.SubSectorPolygon(i), gd, ts, l.frac)
+		s.addWalls(m, gd, ts, l.frac)
 		l.subSectors = append(l.subSectors, s)
 	}
 
-	l.sky = makeGLTexture(gd.Texture(skyName))
+	l.sky = ts.Get(skyName, 0)
+	l.removeListener = m.OnSectorChange(l.sectorChanged)
 
 	return &l
 }
 
-func (s *subSector) addFlats(md *level.Level, gd *goom.GameData, ts glTextureStore) {
-	s.floors, s.ceilings = []*glWorldGeometry{}, []*glWorldGeometry{}
-	var (
-		fseg   = s.ref.Segments()[0]
-		vfs    = md.Vert(fseg.StartVert())
-		line   = md.LinesDefs[fseg.LineDef()]
-		side   = md.SideDefs[line.Right]
-		sector = md.Sectors[side.Sector]
-	)
+// sectorChanged marks the subsectors with flats or walls of a moved sector.
+func (l *doomLevel) sectorChanged(sector int, change level.SectorChange) {
+	if change&(level.SectorFloorChanged|level.SectorCeilingChanged) == 0 {
+		return
+	}
+	l.moving[sector] = true
+	for _, idx := range l.dependents[sector] {
+		l.dirty[idx] = true
+	}
+}
+
+// interpolate marks the subsectors of moving sectors to rebuild their geometry
+// at the heights interpolated by frac, until the sectors stopped moving.
+func (l *doomLevel) interpolate(frac float32) {
+	l.frac = frac
+	for sector := range l.moving {
+		if !l.mapRef.Sectors[sector].Moving() {
+			delete(l.moving, sector)
+		}
+		for _, idx := range l.dependents[sector] {
+			l.dirty[idx] = true
+		}
+	}
+}
+
+// update moves the geometry of a moved subsector, its buffers are updated in place.
+func (l *doomLevel) update(idx int) {
+	if !l.dirty[idx] {
+		return
+	}
+	delete(l.dirty, idx)
+	s := l.subSectors[idx]
+	s.addFlats(l.mapRef, l.mapRef.SubSectorPolygon(idx), l.gd, l.textures, l.frac)
+	s.addWalls(l.mapRef, l.gd, l.textures, l.frac)
+}
 
-	if fseg.Direction() == 1 {
-		side = md.SideDefs[line.Left]
-		sector = md.Sectors[side.Sector]
+// release deletes the buffers of the level and stops listening to its sectors.
+func (l *doomLevel) release() {
+	l.removeListener()
+	for _, s := range l.subSectors {
+		s.release()
 	}
+}
+
+func (s *subSector) release() {
+	for _, geometries := range [][]*glWorldGeometry{s.floors, s.ceilings, s.walls} {
+		for _, g := range geometries {
+			g.release()
+		}
+	}
+}
+
+func (s *subSector) addFlats(md *level.Level, poly []utils.Vec2, gd *goom.GameData, ts glTextureStore, frac float32) {
+	oldFloors, oldCeilings := s.floors, s.ceilings
+	s.floors, s.ceilings = []*glWorldGeometry{}, []*glWorldGeometry{}
+	defer func() {
+		releaseUnused(oldFloors, s.floors)
+		releaseUnused(oldCeilings, s.ceilings)
+	}()
+	sectorRef := md.SectorFromSSect(&s.ref)
+	s.sector = sectorRef
+	if sectorRef == nil || len(poly) < 3 {
+		return
+	}
+	sector := *sectorRef
 
 	floorData := []float32{}
 	ceilData := []float32{}
-	for _, seg := range s.ref.Segments() {
-		var (
-			s = md.Vert(seg.StartVert())
-			e = md.Vert(seg.EndVert())
-		)
-
+	floor, ceil := sector.LerpFloorHeight(frac), sector.LerpCeilHeight(frac)
+	for _, v := range poly {
 		floorData = append(floorData,
-			-vfs.X(), sector.FloorHeight(), vfs.Y(), -vfs.X()/64, vfs.Y()/64,
-			-s.X(), sector.FloorHeight(), s.Y(), -s.X()/64, s.Y()/64,
-			-e.X(), sector.FloorHeight(), e.Y(), -e.X()/64, e.Y()/64,
+			-v.X(), floor, v.Y(), -v.X()/64, v.Y()/64,
 		)
 		ceilData = append(ceilData,
-			-vfs.X(), sector.CeilHeight(), vfs.Y(), -vfs.X()/64, vfs.Y()/64,
-			-s.X(), sector.CeilHeight(), s.Y(), -s.X()/64, s.Y()/64,
-			-e.X(), sector.CeilHeight(), e.Y(), -e.X()/64, e.Y()/64,
+			-v.X(), ceil, v.Y(), -v.X()/64, v.Y()/64,
 		)
 	}
 
-	s.sector = sector
 	if len(gd.Flat(sector.FloorTexture())) > 0 {
-		fm := newGlWorldutils(floorData, sector.LightLevel(), ts[sector.FloorTexture()])
+		fm := reuseGeometry(oldFloors, len(s.floors), floorData, sector.LightLevel(), ts[sector.FloorTexture()])
+		fm.texName, fm.isFlat = sector.FloorTexture(), true
 		s.floors = addGlWorldutils(s.floors, fm)
 	}
 	if len(gd.Flat(sector.CeilTexture())) > 0 {
@@ -87,164 +147,57 @@ func (s *subSector) addFlats(md *level.Level, gd *goom.GameData, ts glTextureSto
 			tex   = sector.CeilTexture()
 			isSky = false
 		)
-		if sector.CeilTexture() == "F_SKY1" {
+		if sector.CeilTexture() == pkg.SkyFlatName {
 			isSky = true
 		}
-		cm := newGlWorldutils(ceilData, md.Sectors[side.Sector].LightLevel(), ts[tex])
+		cm := reuseGeometry(oldCeilings, len(s.ceilings), ceilData, sector.LightLevel(), ts[tex])
 		cm.isSky = isSky
+		cm.texName, cm.isFlat = tex, true
 		s.ceilings = addGlWorldutils(s.ceilings, cm)
 	}
 }
 
-func (s *subSector) addWalls(md *level.Level, gd *goom.GameData, ts glTextureStore) {
+func (s *subSector) addWalls(md *level.Level, gd *goom.GameData, ts glTextureStore, frac float32) {
+	old := s.walls
 	s.walls = []*glWorldGeometry{}
-	for _, seg := range s.ref.Segments() {
-		if seg.LineDef() == -1 {
-			continue
-		}
-		line := md.LinesDefs[seg.LineDef()]
-		side := &md.SideDefs[line.Right]
-
-		otherSide := md.OtherSide(&line, seg)
-		sector := md.Sectors[side.Sector]
-		//isSky := false
-
+	defer func() { releaseUnused(old, s.walls) }()
+	for _, wq := range pkg.SubSectorWalls(md, &s.ref, gd.Textures, frac) {
 		var (
-			start  = md.Vert(uint32(line.Start))
-			end    = md.Vert(uint32(line.End))
-			upTex  = side.Upper()
-			midTex = side.Middle()
-			lowTex = side.Lower()
+			start = wq.Start
+			end   = wq.End
 		)
-
-		if side.Middle() == "-" &&
-			side.Upper() == "-" &&
-			side.Lower() == "-" {
-			continue
+		wallData := []float32{
+			-start.X(), wq.Bottom, start.Y(), wq.U0, wq.VBottom,
+			-start.X(), wq.Top, start.Y(), wq.U0, wq.VTop,
+			-end.X(), wq.Top, end.Y(), wq.U1, wq.VTop,
+
+			-end.X(), wq.Top, end.Y(), wq.U1, wq.VTop,
+			-end.X(), wq.Bottom, end.Y(), wq.U1, wq.VBottom,
+			-start.X(), wq.Bottom, start.Y(), wq.U0, wq.VBottom,
 		}
-
-		var lside *level.SideDef
-
-		if line.Left > 0 {
-			lside = &md.SideDefs[line.Left]
-			if side.Upper() == "-" {
-				upTex = lside.Upper()
-			}
-			if side.Middle() == "-" {
-				midTex = lside.Middle()
-			}
-			if side.Lower() == "-" {
-				lowTex = lside.Lower()
-			}
-		}
-
-		dist := start.DistanceTo(end)
-
-		if upTex != "-" && otherSide != nil {
-			oppositeSector := md.Sectors[otherSide.Sector]
-			if _, ok := ts[upTex]; !ok {
-				fmt.Println(upTex, "not found")
-				continue
-			}
-			tex := ts[upTex][0]
-			var (
-				tw     = float32(tex.image.Width()) + float32(tex.image.Left())
-				th     = float32(tex.image.Height()) + float32(tex.image.Top())
-				height = oppositeSector.CeilHeight() - sector.CeilHeight()
-				el     = dist / tw
-			)
-
-			wallData := []float32{
-				-start.X(), sector.CeilHeight(), start.Y(), 0, height / th,
-				-start.X(), oppositeSector.CeilHeight(), start.Y(), 0, 0.0,
-				-end.X(), oppositeSector.CeilHeight(), end.Y(), el, 0.0,
-
-				-end.X(), oppositeSector.CeilHeight(), end.Y(), el, 0.0,
-				-end.X(), sector.CeilHeight(), end.Y(), el, height / th,
-				-start.X(), sector.CeilHeight(), start.Y(), 0, height / th,
-			}
-			if gd.Texture(upTex) != nil {
-				wm := newGlWorldutils(wallData, sector.LightLevel(), ts[upTex])
-				if oppositeSector.CeilTexture() == "F_SKY1" {
-					wm.isSky = true
-				}
-				s.walls = addGlWorldutils(s.walls, wm)
-			}
-		}
-
-		if lowTex != "-" && otherSide != nil {
-			oppositeSector := md.Sectors[otherSide.Sector]
-			if _, ok := ts[lowTex]; !ok {
-				fmt.Println(lowTex, "not found")
-				continue
-			}
-			tex := ts[lowTex][0]
-			var (
-				tw     = float32(tex.image.Width()) + float32(tex.image.Left())
-				th     = float32(tex.image.Height()) + float32(tex.image.Top())
-				height = sector.FloorHeight() - oppositeSector.FloorHeight()
-				el     = dist / tw
-			)
-
-			wallData := []float32{
-				-start.X(), sector.FloorHeight(), start.Y(), 0, height / th,
-				-start.X(), oppositeSector.FloorHeight(), start.Y(), 0, 0.0,
-				-end.X(), oppositeSector.FloorHeight(), end.Y(), el, 0.0,
-
-				-end.X(), oppositeSector.FloorHeight(), end.Y(), el, 0.0,
-				-end.X(), sector.FloorHeight(), end.Y(), el, height / th,
-				-start.X(), sector.FloorHeight(), start.Y(), 0, height / th,
-			}
-			if gd.Texture(lowTex) != nil {
-				wm := newGlWorldutils(wallData, sector.LightLevel(), ts[lowTex])
-				if lowTex == "F_SKY1" {
-					wm.isSky = true
-				}
-				s.walls = addGlWorldutils(s.walls, wm)
-			}
-		}
-
-		if midTex != "-" {
-			if _, ok := ts[midTex]; !ok {
-				fmt.Println(midTex, "not found")
-				continue
-			}
-			tex := ts[midTex][0]
-			var (
-				tw     = float32(tex.image.Width()) + float32(tex.image.Left())
-				th     = float32(tex.image.Height()) + float32(tex.image.Top())
-				height = sector.CeilHeight() - sector.FloorHeight()
-				el     = dist / tw
-			)
-
-			wallData := []float32{
-				-start.X(), sector.FloorHeight(), start.Y(), 0, height / th,
-				-start.X(), sector.CeilHeight(), start.Y(), 0, 0,
-				-end.X(), sector.CeilHeight(), end.Y(), el, 0,
-
-				-end.X(), sector.CeilHeight(), end.Y(), el, 0.0,
-				-end.X(), sector.FloorHeight(), end.Y(), el, height / th,
-				-start.X(), sector.FloorHeight(), start.Y(), 0, height / th,
-			}
-			wm := newGlWorldutils(wallData, sector.LightLevel(), ts[midTex])
-			if midTex == "F_SKY1" {
-				wm.isSky = true
-			}
-			s.walls = addGlWorldutils(s.walls, wm)
+		wm := reuseGeometry(old, len(s.walls), wallData, wq.Light, ts[wq.Texture])
+		wm.isSky = wq.IsSky
+		wm.texName = wq.Texture
+		wm.side, wm.part = wq.Side, wq.Part
+		wm.masked = nil
+		if wq.Masked {
+			masked := wq
+			wm.masked = &masked
 		}
+		s.walls = addGlWorldutils(s.walls, wm)
 	}
 }
 
-func (s *subSector) Draw(ts glTextureStore) {
+func (s *subSector) Draw(ts glTextureStore, anims *graphics.Animations, tic int) {
 	for i := 0; i < len(s.floors); i++ {
-		s.floors[i].Draw(gl.TRIANGLE_FAN)
+		s.floors[i].DrawWithTexture(gl.TRIANGLE_FAN, s.floors[i].frameTexture(ts, anims, tic))
 		if len(s.ceilings) > i && !s.ceilings[i].isSky {
-			s.ceilings[i].Draw(gl.TRIANGLE_FAN)
+			s.ceilings[i].DrawWithTexture(gl.TRIANGLE_FAN, s.ceilings[i].frameTexture(ts, anims, tic))
 		}
 	}
 	for _, w := range s.walls {
-		if !w.isSky {
-			w.Draw(gl.TRIANGLES)
+		if !w.isSky && w.masked == nil {
+			w.DrawWithTexture(gl.TRIANGLES, w.frameTexture(ts, anims, tic))
 		}
 	}
 }
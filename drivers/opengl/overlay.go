@@ -0,0 +1,97 @@
+package opengl
+
+import (
+	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/tinogoehlert/goom/debug"
+	"github.com/tinogoehlert/goom/graphics"
+)
+
+// overlayClear is the transparent palette index of the overlay, like in indexed textures.
+const overlayClear = 255
+
+// glOverlay is the debug overlay, its lines are drawn on the CPU at the
+// framebuffer size and uploaded as a texture every frame.
+type glOverlay struct {
+	tex           *glTexture
+	width, height int
+	// pix palette indices of the lines
+	pix []uint8
+	// rgba of the lines for true color, cleared pixels are transparent
+	rgba []uint8
+}
+
+func newGLOverlay(width, height int, indexed bool) *glOverlay {
+	ov := &glOverlay{
+		tex:    &glTexture{indexed: indexed, uvRect: fullUV},
+		width:  width,
+		height: height,
+		pix:    make([]uint8, width*height),
+	}
+	if !indexed {
+		ov.rgba = make([]uint8, width*height*4)
+	}
+	gl.GenTextures(1, &ov.tex.ID)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, ov.tex.ID)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	return ov
+}
+
+// update draws the lines of the overlay and uploads them.
+func (ov *glOverlay) update(o *debug.Overlay) {
+	for i := range ov.pix {
+		ov.pix[i] = overlayClear
+	}
+	o.View(ov.width, ov.height).DrawLines(o.Lines, func(x, y int, color uint8) {
+		ov.pix[y*ov.width+x] = color
+	})
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, ov.tex.ID)
+	if ov.tex.indexed {
+		genGLLookupTexture(ov.pix, ov.width, ov.height, gl.RED)
+		return
+	}
+	colors := graphics.DefaultPalette().Colors
+	for i, index := range ov.pix {
+		if index == overlayClear {
+			copy(ov.rgba[i*4:], []uint8{0, 0, 0, 0})
+			continue
+		}
+		c := colors[index]
+		copy(ov.rgba[i*4:], []uint8{c.R, c.G, c.B, 255})
+	}
+	genGLLookupTexture(ov.rgba, ov.width, ov.height, gl.RGBA)
+}
+
+func (ov *glOverlay) release() {
+	gl.DeleteTextures(1, &ov.tex.ID)
+}
+
+// DrawOverlay draws the lines of the debug overlay on top of the frame.
+func (gr *GLRenderer) DrawOverlay(o *debug.Overlay) {
+	if gr.overlay != nil {
+		if gr.overlay.width != gr.fbWidth || gr.overlay.height != gr.fbHeight {
+			gr.overlay.release()
+			gr.overlay = nil
+		}
+	}
+	if gr.overlay == nil {
+		gr.overlay = newGLOverlay(gr.fbWidth, gr.fbHeight, gr.indexed)
+	}
+	gr.overlay.update(o)
+	gr.spriter.reset()
+
+	var (
+		aspect = gr.fbAspectRatio
+		width  = hudHeight * aspect
+	)
+	gr.setUpHudShader(aspect)
+	gr.SetLight(fullBright)
+	// the overlay covers the screen
+	gr.shaders[gr.currentShader].Uniform2f("billboard_size", mgl32.Vec2{width / spriteQuadSize, hudHeight / spriteQuadSize})
+	gr.shaders[gr.currentShader].Uniform3f("billboard_pos", mgl32.Vec3{width / 2, hudHeight / 2, 0})
+	gr.drawSprite(gr.overlay.tex)
+	gr.shaders[gr.currentShader].Uniform1i("draw_phase", 0)
+}
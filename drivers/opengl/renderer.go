@@ -2,17 +2,43 @@ package opengl
 
 import (
 	"fmt"
+	"image"
+	"image/color"
+	"math"
 	"time"
 
 	"github.com/go-gl/gl/v2.1/gl"
 	"github.com/go-gl/mathgl/mgl32"
 
+	"github.com/tinogoehlert/goom/drivers/pkg"
 	"github.com/tinogoehlert/goom/game"
 	"github.com/tinogoehlert/goom/goom"
 	"github.com/tinogoehlert/goom/graphics"
 	"github.com/tinogoehlert/goom/level"
+	"github.com/tinogoehlert/goom/utils"
 )
 
+const (
+	// fovY vertical field of view in radians, the 64 degrees of the software renderer
+	fovY = 64 * math.Pi / 180
+	// fovMargin widens the culling frustum to keep geometry at the screen edges
+	fovMargin = 10
+	// hudHeight height of the HUD coordinate system
+	hudHeight = 640
+	// fullBright light level drawing HUD graphics with their original colors
+	fullBright = 400
+)
+
+// Options configures the GL renderer.
+type Options struct {
+	// IndexedColor uploads textures as palette indices and applies the
+	// COLORMAP in the shader, giving Doom's banded sector light and distance fade.
+	IndexedColor bool
+	// Width and Height of the internal resolution the frames are scaled from,
+	// e.g. 320x200 for Doom's look. Zero renders at the window size.
+	Width, Height int
+}
+
 //GLRenderer openGL renderer
 type GLRenderer struct {
 	currentLevel  *doomLevel
@@ -26,6 +52,34 @@ type GLRenderer struct {
 	spriter       *glSpriter
 	lastTick      time.Time
 	currentShader string
+	indexed       bool
+	paletteTex    uint32
+	colormapTex   uint32
+	fuzzTex       uint32
+	fixedColormap int
+	animations    *graphics.Animations
+	sprites       graphics.SpriteStore
+	levelTime     int
+	palettes      *graphics.Palettes
+	paletteIndex  int
+	gamma         int
+	paletteDirty  bool
+	// tint of the effect palette for true color, updated when the palette is dirty
+	tint color.RGBA
+	// frac of the time between the last and the next game tic
+	frac float32
+	// masked walls and sprites drawn back to front after the opaque geometry
+	masked pkg.MaskedPass
+	// fb is the offscreen framebuffer of the internal resolution resWidth x resHeight,
+	// which is scaled to the window size winWidth x winHeight
+	fb                  *glFramebuffer
+	resWidth, resHeight int
+	winWidth, winHeight int
+	// fbFailed is the size the framebuffer could not be created at,
+	// it is not tried again until the size changes
+	fbFailed [2]int
+	// overlay of the debug lines, created on the first DrawOverlay
+	overlay *glOverlay
 }
 
 // Init initialize glfw
@@ -41,38 +95,58 @@ func Init() error {
 }
 
 // NewRenderer initialize the renderer
-func NewRenderer(gd *goom.GameData) (*GLRenderer, error) {
+func NewRenderer(gd *goom.GameData, opts Options) (*GLRenderer, error) {
 	gr := &GLRenderer{
 		shaders:       make(map[string]*ShaderProgram),
 		camera:        NewCamera(),
 		modelMatrix:   mgl32.Ident4(),
 		currentShader: "main",
 		textures:      newGLTextureStore(),
+		indexed:       opts.IndexedColor,
+		fixedColormap: -1,
+		animations:    gd.Animations,
+		sprites:       gd.Sprites,
+		palettes:      gd.Palettes,
+		resWidth:      opts.Width,
+		resHeight:     opts.Height,
+	}
+
+	if gr.indexed {
+		if gd.Colormap == nil {
+			return nil, fmt.Errorf("indexed color requires a COLORMAP lump")
+		}
+		gr.paletteTex, gr.colormapTex = genGLColorTables(gd.DefaultPalette(), gd.Colormap)
 	}
+	gr.fuzzTex = genGLFuzzTable()
 
 	for k, v := range gd.Textures {
 		gr.textures.initTexture(k, 1)
-		gr.textures.addTexture(k, 0, v)
+		gr.textures.addTexture(k, 0, v, gr.indexed)
 	}
 
 	for k, v := range gd.Flats {
 		gr.textures.initTexture(k, 1)
-		gr.textures.addTexture(k, 0, v[0])
+		gr.textures.addTexture(k, 0, v[0], gr.indexed)
 	}
 
+	// sprites, fonts and HUD graphics share the pages of an atlas
+	sources := []atlasSource{}
 	for k, v := range gd.Fonts.GetAllGraphics() {
-		gr.textures.initTexture(k, 1)
-		gr.textures.addTexture(k, 0, v)
+		sources = append(sources, atlasSource{k, 0, v})
+	}
+
+	for k, v := range gd.Graphics {
+		sources = append(sources, atlasSource{k, 0, v})
 	}
 
 	for _, v := range gd.Sprites {
 		v.Frames(func(f *graphics.SpriteFrame) {
-			gr.textures.initTexture(f.Name(), len(f.Angles()))
 			for i, img := range f.Angles() {
-				gr.textures.addTexture(f.Name(), i, img)
+				sources = append(sources, atlasSource{f.Name(), i, img})
 			}
 		})
 	}
+	gr.textures.addAtlas(sources, gr.indexed)
 	gr.spriter = NewSpriter()
 	return gr, nil
 }
@@ -108,20 +182,43 @@ func (gr *GLRenderer) SetShaderProgram(name string) error {
 
 // BuildLevel builds the level
 func (gr *GLRenderer) LoadLevel(m *level.Level, gd *goom.GameData) {
-	gr.currentLevel = RegisterMap(m, gd, gr.textures, "SKY1")
+	if gr.currentLevel != nil {
+		gr.currentLevel.release()
+	}
+	gr.currentLevel = RegisterMap(m, gd, gr.textures, gd.Sky(m.Name))
 }
 
 func (gr *GLRenderer) Camera() *Camera {
 	return gr.camera
 }
 
+// Frustum gets the horizontal view frustum of the camera on the map.
+// It is nil if the camera pitch is too steep to cull by the horizontal view.
+func (gr *GLRenderer) Frustum() *level.Frustum {
+	if gr.fbAspectRatio == 0 || math.Abs(float64(gr.camera.direction.Z())) > 0.5 {
+		return nil
+	}
+	var (
+		half = math.Atan(math.Tan(fovY/2) * float64(gr.fbAspectRatio))
+		fov  = float32(2*half*180/math.Pi) + fovMargin
+	)
+	if fov >= 180 {
+		return nil
+	}
+	return level.NewFrustum(
+		utils.V2(gr.camera.position.X(), gr.camera.position.Y()),
+		utils.V2(-gr.camera.direction.X(), gr.camera.direction.Y()),
+		fov,
+	)
+}
+
 func (gr *GLRenderer) SetLight(light float32) {
 	gr.shaders[gr.currentShader].Uniform1f("sectorLight", light)
 }
 
 func (gr *GLRenderer) setProjection() {
 	gr.shaders[gr.currentShader].UniformMatrix4fv("projection",
-		mgl32.Perspective(64, gr.fbAspectRatio, 1.0, 8000.0),
+		mgl32.Perspective(fovY, gr.fbAspectRatio, 1.0, 8000.0),
 	)
 }
 
@@ -133,11 +230,31 @@ func (gr *GLRenderer) setModel() {
 	gr.shaders[gr.currentShader].UniformMatrix4fv("model", gr.modelMatrix)
 }
 
+// SetLevelTime sets the game tic used to pick the frames of animated flats and textures.
+func (gr *GLRenderer) SetLevelTime(tic int) {
+	gr.levelTime = tic
+}
+
+// SetInterpolation sets the fraction of the tic things and moving sectors are interpolated with.
+func (gr *GLRenderer) SetInterpolation(frac float32) {
+	gr.frac = frac
+	if gr.currentLevel != nil {
+		gr.currentLevel.interpolate(frac)
+	}
+}
+
 func (gr *GLRenderer) SetPlayerPosition(pos mgl32.Vec3) {
 	gr.shaders[gr.currentShader].Uniform3f("player_pos", pos)
 }
 
+// SetCamera sets the camera and the player position the light fades with.
+func (gr *GLRenderer) SetCamera(pos [2]float32, dir [3]float32, height float32) {
+	gr.camera.SetCamera(pos, dir, height)
+	gr.SetPlayerPosition(mgl32.Vec3{-pos[0], height, pos[1]})
+}
+
 func (gr *GLRenderer) DrawSubSector(idx int) {
+	gr.currentLevel.update(idx)
 	var s = gr.currentLevel.subSectors[idx]
 
 	//gl.Disable(gl.DEPTH_TEST)
@@ -145,8 +262,19 @@ func (gr *GLRenderer) DrawSubSector(idx int) {
 	s.DrawSky(gr.textures, gr.currentLevel.sky)
 	//gl.Enable(gl.DEPTH_TEST)
 	gr.shaders[gr.currentShader].Uniform1i("draw_phase", 0)
-	gr.SetLight(s.sector.LightLevel())
-	s.Draw(gr.textures)
+	if s.sector != nil {
+		gr.SetLight(s.sector.LightLevel())
+	}
+	s.Draw(gr.textures, gr.animations, gr.levelTime)
+	gr.spriter.reset()
+
+	cam := utils.V2(gr.camera.position.X(), gr.camera.position.Y())
+	for _, w := range s.walls {
+		if w.masked != nil {
+			w := w
+			gr.masked.Add(w.masked.Distance(cam), func() { gr.drawMaskedWall(w) })
+		}
+	}
 }
 
 func (gr *GLRenderer) GetSectorForSSect(ssect *level.SubSector) level.Sector {
@@ -159,35 +287,120 @@ func (gr *GLRenderer) GetSectorForSSect(ssect *level.SubSector) level.Sector {
 	return sector
 }
 
+// DrawThings draws the things as billboards, together with the masked walls
+// of the drawn subsectors back to front.
 func (gr *GLRenderer) DrawThings(things []game.Thingable) {
-	gr.shaders[gr.currentShader].Uniform1i("draw_phase", 1)
-
+	cam := utils.V2(gr.camera.position.X(), gr.camera.position.Y())
 	for _, t := range things {
 		if !t.IsShown() {
 			continue
 		}
-		f := t.NextFrame()
-		a, flipped := t.CalcAngle(gr.camera.position)
-		img := gr.textures.Get(t.SpriteName()+string(f), a)
-		gr.SetLight(t.GetSector().LightLevel())
-		gr.shaders[gr.currentShader].Uniform3f("billboard_pos", mgl32.Vec3{
-			-t.Position()[0],
-			t.Height() + (float32(img.image.Height()) / 2.2),
-			t.Position()[1],
+		sprite, ok := gr.sprites[t.SpriteName()]
+		if !ok {
+			continue
+		}
+		frame := t.NextFrame()
+		view, ok := sprite.View(frame, t.Rotation(gr.camera.position))
+		if !ok {
+			continue
+		}
+		img := gr.textures.Get(view.Name, view.Rotation)
+		if img == nil {
+			continue
+		}
+		var (
+			t             = t
+			pos           = t.LerpPosition(gr.frac)
+			z             = t.LerpHeight(gr.frac)
+			flip          = view.Flip
+			light float32 = 255
+			floor         = z
+		)
+		if sector := t.GetSector(); sector != nil {
+			light, floor = sector.LightLevel(), sector.LerpFloorHeight(gr.frac)
+		}
+		if t.Bright(frame) {
+			light = fullBright
+		}
+		sq, ok := pkg.PlaceSprite(z, floor, img.image.Width(), img.image.Height(), img.image.Left(), img.image.Top())
+		if !ok {
+			continue
+		}
+		if flip {
+			sq = sq.Flipped()
+		}
+		var (
+			right  = gr.cameraRight()
+			mid    = (sq.Left + sq.Right) / 2
+			center = mgl32.Vec3{-pos[0], (sq.Top + sq.Bottom) / 2, pos[1]}.Add(right.Mul(mid))
+			uvRect = img.uvRect
+		)
+		// rows below the floor are cut off the bottom of the picture
+		uvRect[3] *= sq.VBottom
+		gr.masked.Add(cam.DistanceTo(utils.V2(pos[0], pos[1])), func() {
+			gr.shaders[gr.currentShader].Uniform1i("draw_phase", 1)
+			gr.setRenderStyle(t.RenderStyle())
+			gr.SetLight(light)
+			gr.shaders[gr.currentShader].Uniform3f("billboard_pos", center)
+
+			flipped := 0
+			if flip {
+				flipped = 1
+			}
+			gr.shaders[gr.currentShader].Uniform1i("billboard_flipped", flipped)
+			// one texel is one map unit
+			gr.shaders[gr.currentShader].Uniform2f("billboard_size", mgl32.Vec2{sq.Width() / spriteQuadSize, sq.Height() / spriteQuadSize})
+			gr.shaders[gr.currentShader].Uniform4f("uv_rect", uvRect)
+			gr.spriter.Draw(gl.TRIANGLES, img)
 		})
+	}
+	gr.shaders[gr.currentShader].Uniform1f("translucency", graphics.TranslucencyAlpha)
+	gr.shaders[gr.currentShader].Uniform1f("fuzz_seed", float32(gr.levelTime))
+	gr.masked.Flush()
+	gr.setRenderStyle(game.StyleNormal)
+	gr.shaders[gr.currentShader].Uniform1i("draw_phase", 0)
+}
 
-		gr.shaders[gr.currentShader].Uniform1i("billboard_flipped", flipped)
-		gr.shaders[gr.currentShader].Uniform2f("billboard_size", mgl32.Vec2{
-			float32(img.image.Width()) / 150,
-			float32(img.image.Height()) / 130,
-		})
-		gr.spriter.Draw(gl.TRIANGLES, img)
+// cameraRight gets the horizontal right axis of the view in GL coordinates.
+func (gr *GLRenderer) cameraRight() mgl32.Vec3 {
+	right := mgl32.Vec3{-gr.camera.direction.Y(), 0, gr.camera.direction.X()}
+	if right.Len() == 0 {
+		return mgl32.Vec3{1, 0, 0}
 	}
+	return right.Normalize()
+}
+
+// drawMaskedWall draws a see-through middle texture, its transparent pixels are discarded.
+func (gr *GLRenderer) drawMaskedWall(w *glWorldGeometry) {
 	gr.shaders[gr.currentShader].Uniform1i("draw_phase", 0)
+	gr.setRenderStyle(game.StyleNormal)
+	gr.SetLight(w.masked.LightLevel())
+	w.DrawWithTexture(gl.TRIANGLES, w.frameTexture(gr.textures, gr.animations, gr.levelTime))
+	gr.spriter.reset()
+}
+
+// setRenderStyle sets how the following sprites are blended. Translucent and fuzzy
+// sprites do not write the depth, the masked pass draws what is behind them first.
+func (gr *GLRenderer) setRenderStyle(style game.RenderStyle) {
+	gr.shaders[gr.currentShader].Uniform1i("render_mode", int(style))
+	if style == game.StyleNormal {
+		gl.Disable(gl.BLEND)
+		gl.DepthMask(true)
+		return
+	}
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	gl.DepthMask(false)
+}
+
+// drawSprite draws the sprite quad with the image, which may be a region of an atlas.
+func (gr *GLRenderer) drawSprite(img *glTexture) {
+	gr.shaders[gr.currentShader].Uniform4f("uv_rect", img.uvRect)
+	gr.spriter.Draw(gl.TRIANGLES, img)
 }
 
 func (gr *GLRenderer) setUpHudShader(aspect float32) {
-	ortho := mgl32.Ortho2D(640*aspect, 0, 0, 640)
+	ortho := mgl32.Ortho2D(hudHeight*aspect, 0, 0, hudHeight)
 	gl.Disable(gl.DEPTH_TEST) // Disable the Depth-testing
 	gr.shaders[gr.currentShader].UniformMatrix4fv("ortho", ortho)
 	gr.shaders[gr.currentShader].Uniform1i("draw_phase", 2)
@@ -206,17 +419,18 @@ func (gr *GLRenderer) drawHudImage(sprite string, pos mgl32.Vec3, offsetX, offse
 	pos[1] += float32(-img.image.Top()) + offsetY
 	pos[0] += +offsetX
 	gr.shaders[gr.currentShader].Uniform3f("billboard_pos", pos)
-	gr.spriter.Draw(gl.TRIANGLES, img)
+	gr.drawSprite(img)
 }
 
 // DrawHUD draws the game hud
 func (gr *GLRenderer) DrawHUD(player *game.Player, t float64) {
-	aspect := float32(gr.fbWidth) / float32(gr.fbHeight)
+	aspect := gr.fbAspectRatio
 
 	gr.setUpHudShader(aspect)
 	gr.SetLight(player.GetSector().LightLevel())
 	w := player.Weapon()
 	frame, fire := w.NextFrames(t)
+	offset := w.LerpOffset(gr.frac)
 
 	pos := mgl32.Vec3{(640 * aspect) / 2, 0, 0}
 	scaleFactor := float32(1)
@@ -224,8 +438,8 @@ func (gr *GLRenderer) DrawHUD(player *game.Player, t float64) {
 	gr.drawHudImage(
 		w.Sprite+string(frame),
 		pos,
-		+w.Offset()[0],
-		-w.Offset()[1]-30,
+		+offset[0],
+		-offset[1]-30,
 		scaleFactor,
 	)
 
@@ -233,8 +447,8 @@ func (gr *GLRenderer) DrawHUD(player *game.Player, t float64) {
 		gr.drawHudImage(
 			w.FireSprite+string(fire),
 			pos,
-			w.FireOffset.X+w.Offset()[0],
-			w.FireOffset.Y+(-w.Offset()[1])-30,
+			w.FireOffset.X+offset[0],
+			w.FireOffset.Y+(-offset[1])-30,
 			scaleFactor,
 		)
 	}
@@ -248,19 +462,196 @@ func (gr *GLRenderer) DrawHUdElement(name string, xpos, ypos float32, scaleFacto
 	gr.shaders[gr.currentShader].Uniform1i("draw_phase", 0)
 }
 
-func (gr *GLRenderer) SetViewPort(fbWidth, fbHeight int) {
+// DrawGraphic draws a graphic at a position on the original 320x200 screen,
+// which is scaled to the window height and centered.
+func (gr *GLRenderer) DrawGraphic(name string, x, y float32) {
+	img := gr.textures.Get(name, 0)
+	if img == nil || img.image == nil {
+		return
+	}
+	gr.setUpHudShader(gr.fbAspectRatio)
+	gr.SetLight(fullBright)
+	var (
+		unit = float32(hudHeight) / pkg.ScreenHeight
+		// the HUD x axis starts at the right edge
+		left = (hudHeight*gr.fbAspectRatio + pkg.ScreenWidth*unit) / 2
+		w    = float32(img.image.Width())
+		h    = float32(img.image.Height())
+		cx   = left - (x-float32(img.image.Left())+w/2)*unit
+		cy   = hudHeight - (y-float32(img.image.Top())+h/2)*unit
+	)
+	gr.shaders[gr.currentShader].Uniform2f("billboard_size", mgl32.Vec2{w * unit / spriteQuadSize, h * unit / spriteQuadSize})
+	gr.shaders[gr.currentShader].Uniform3f("billboard_pos", mgl32.Vec3{cx, cy, 0})
+	gr.drawSprite(img)
+	gr.shaders[gr.currentShader].Uniform1i("draw_phase", 0)
+}
+
+// SetViewPort sets the window size, the frame is rendered at the internal
+// resolution and stretched over the window keeping the window's aspect ratio.
+func (gr *GLRenderer) SetViewPort(width, height int) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	gr.winWidth, gr.winHeight = width, height
+	fbWidth, fbHeight := width, height
+	if gr.resWidth > 0 && gr.resHeight > 0 {
+		fbWidth, fbHeight = gr.resWidth, gr.resHeight
+	}
+	if gr.fbFailed == [2]int{fbWidth, fbHeight} {
+		// draw into the window directly
+		if gr.fb != nil {
+			gr.fb.release()
+			gr.fb = nil
+		}
+		fbWidth, fbHeight = width, height
+	} else if gr.fb == nil || gr.fb.width != fbWidth || gr.fb.height != fbHeight {
+		if gr.fb != nil {
+			gr.fb.release()
+		}
+		fb, err := newGLFramebuffer(fbWidth, fbHeight)
+		if err != nil {
+			utils.GoomConsole.Red("could not render offscreen: %s", err.Error())
+			gr.fbFailed = [2]int{fbWidth, fbHeight}
+			fbWidth, fbHeight = width, height
+		}
+		gr.fb = fb
+	}
 	gr.fbWidth = fbWidth
 	gr.fbHeight = fbHeight
-	gr.fbAspectRatio = float32(gr.fbWidth) / float32(gr.fbHeight)
-	gl.Viewport(0, 0, int32(gr.fbWidth), int32(gr.fbHeight))
+	gr.fbAspectRatio = float32(width) / float32(height)
+	gr.bindFramebuffer()
 	gr.setProjection()
+	gr.setSkyView()
+}
+
+// bindFramebuffer directs the draw calls into the offscreen framebuffer, if there is one.
+func (gr *GLRenderer) bindFramebuffer() {
+	if gr.fb != nil {
+		gr.fb.bind()
+		return
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Viewport(0, 0, int32(gr.fbWidth), int32(gr.fbHeight))
+}
+
+// FinishFrame scales the frame of the internal resolution onto the window.
+func (gr *GLRenderer) FinishFrame() {
+	if gr.fb != nil {
+		gr.fb.blit(gr.winWidth, gr.winHeight)
+	}
+}
+
+// Screenshot reads the last frame back at the internal resolution.
+func (gr *GLRenderer) Screenshot() (*image.RGBA, error) {
+	if gr.fb == nil {
+		return nil, fmt.Errorf("could not take screenshot: no offscreen framebuffer")
+	}
+	return gr.fb.read(), nil
+}
+
+// setSkyView sets the screen space cylinder the sky is drawn with, like the
+// software renderer its columns follow the yaw and its rows the horizon.
+func (gr *GLRenderer) setSkyView() {
+	if gr.currentLevel == nil || gr.currentLevel.sky == nil || gr.fbHeight == 0 {
+		return
+	}
+	var (
+		dir   = gr.camera.direction
+		l     = float32(math.Hypot(float64(dir.X()), float64(dir.Y())))
+		focal = float32(gr.fbHeight) / 2 / float32(math.Tan(fovY/2))
+		yaw   = float32(math.Atan2(float64(dir.Y()), float64(-dir.X())))
+		sky   = gr.currentLevel.sky.image
+	)
+	// pixels of the internal resolution are not square when stretched over the window
+	focalX := focal * float32(gr.fbWidth) / float32(gr.fbHeight) / gr.fbAspectRatio
+	if l == 0 {
+		l = 1
+	}
+	gr.shaders[gr.currentShader].Uniform4f("sky_view", [4]float32{
+		float32(gr.fbWidth) / 2,
+		float32(gr.fbHeight)/2 + dir.Z()/l*focal,
+		focal,
+		yaw,
+	})
+	gr.shaders[gr.currentShader].Uniform4f("sky_size", [4]float32{
+		float32(sky.Width()),
+		float32(sky.Height()),
+		float32(gr.fbHeight),
+		focalX,
+	})
+}
+
+// SetInvulnerability switches to the greyscale colormap of the invulnerability sphere.
+// It has no effect without indexed color.
+func (gr *GLRenderer) SetInvulnerability(enabled bool) {
+	gr.fixedColormap = -1
+	if enabled {
+		gr.fixedColormap = graphics.InvulnerabilityColormap
+	}
+}
+
+// SetPalette selects the PLAYPAL palette of damage, pickup and power up effects.
+func (gr *GLRenderer) SetPalette(index int) {
+	gr.paletteDirty = gr.paletteDirty || index != gr.paletteIndex
+	gr.paletteIndex = index
+}
+
+// SetGamma sets the gamma correction level, see graphics.NumGammaLevels.
+func (gr *GLRenderer) SetGamma(level int) {
+	gr.paletteDirty = gr.paletteDirty || level != gr.gamma
+	gr.gamma = level
+}
+
+// bindColorTables binds palette and colormap to the texture units 1 and 2,
+// the fuzz offsets to unit 3.
+// With indexed color the effect palette is uploaded with gamma applied,
+// otherwise the shader blends with the effect's tint and applies the gamma.
+func (gr *GLRenderer) bindColorTables() {
+	shader := gr.shaders[gr.currentShader]
+	shader.Uniform1i("tex", 0)
+	shader.Uniform1i("palette", 1)
+	shader.Uniform1i("colormap", 2)
+	shader.Uniform1i("fuzz_offsets", 3)
+	shader.Uniform1i("fixed_colormap", gr.fixedColormap)
+	gl.ActiveTexture(gl.TEXTURE3)
+	gl.BindTexture(gl.TEXTURE_2D, gr.fuzzTex)
+	gl.ActiveTexture(gl.TEXTURE0)
+	if !gr.indexed {
+		shader.Uniform1i("indexed", 0)
+		if gr.paletteDirty && gr.palettes != nil {
+			gr.tint = gr.palettes.Get(gr.paletteIndex).Tint(gr.palettes.Get(0))
+			gr.paletteDirty = false
+		}
+		tint := gr.tint
+		shader.Uniform4f("tint", [4]float32{
+			float32(tint.R) / 255, float32(tint.G) / 255, float32(tint.B) / 255, float32(tint.A) / 255,
+		})
+		shader.Uniform1f("gamma", graphics.GammaExponent(gr.gamma))
+		return
+	}
+	shader.Uniform1i("indexed", 1)
+	shader.Uniform4f("tint", [4]float32{})
+	shader.Uniform1f("gamma", 1)
+	gl.ActiveTexture(gl.TEXTURE1)
+	gl.BindTexture(gl.TEXTURE_2D, gr.paletteTex)
+	if gr.paletteDirty && gr.palettes != nil {
+		updateGLPalette(gr.palettes.Get(gr.paletteIndex).WithGamma(gr.gamma))
+		gr.paletteDirty = false
+	}
+	gl.ActiveTexture(gl.TEXTURE2)
+	gl.BindTexture(gl.TEXTURE_2D, gr.colormapTex)
+	gl.ActiveTexture(gl.TEXTURE0)
 }
 
 func (gr *GLRenderer) RenderNewFrame() {
+	gr.bindFramebuffer()
 	gl.Enable(gl.DEPTH_TEST)
 	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 	gr.shaders[gr.currentShader].Use()
+	gr.bindColorTables()
 	gr.setView()
 	gr.setModel()
-
+	gr.setSkyView()
+	gr.spriter.reset()
+	gr.masked.Reset()
 }
@@ -67,6 +67,14 @@ func (sp *ShaderProgram) Uniform2f(name string, vec2 [2]float32) {
 	gl.Uniform2fv(sp.uniforms[name], 1, &vec2[0])
 }
 
+// Uniform4f set vec4 uniform variable
+func (sp *ShaderProgram) Uniform4f(name string, vec4 [4]float32) {
+	if _, ok := sp.uniforms[name]; !ok {
+		sp.uniforms[name] = gl.GetUniformLocation(sp.id, gl.Str(name+"\x00"))
+	}
+	gl.Uniform4fv(sp.uniforms[name], 1, &vec4[0])
+}
+
 // UniformMatrix4fv set 4x4 matrix float32 uniform variable
 func (sp *ShaderProgram) UniformMatrix4fv(name string, mat4 [16]float32) {
 	if _, ok := sp.uniforms[name]; !ok {
@@ -4,20 +4,27 @@ import (
 	"github.com/go-gl/gl/v2.1/gl"
 )
 
+// spriteQuadSize width and height of the spriter quad in the units
+// of billboard_size, a size of quad units / spriteQuadSize is drawn
+const spriteQuadSize = 120
+
 type glSpriter struct {
 	vao      uint32
 	meshSize int
+	// bound texture of the last sprite, sprites of an atlas page share it
+	bound uint32
 }
 
 func NewSpriter() *glSpriter {
+	const h = spriteQuadSize / 2
 	verts := []float32{
-		-60, -60, 0, 0.0, 1.0,
-		-60, 60, 0, 0.0, 0.0,
-		60, 60, 0, 1.0, 0.0,
+		-h, -h, 0, 0.0, 1.0,
+		-h, h, 0, 0.0, 0.0,
+		h, h, 0, 1.0, 0.0,
 
-		-60, -60, 0, 0.0, 1.0,
-		60, 60, 0, 1.0, 0.0,
-		60, -60, 0, 1.0, 1.0,
+		-h, -h, 0, 0.0, 1.0,
+		h, h, 0, 1.0, 0.0,
+		h, -h, 0, 1.0, 1.0,
 	}
 	gls := &glSpriter{
 		meshSize: len(verts) / 5,
@@ -41,7 +48,15 @@ func NewSpriter() *glSpriter {
 }
 
 func (gls *glSpriter) Draw(method uint32, tex *glTexture) {
-	gl.BindTexture(gl.TEXTURE_2D, tex.ID)
+	if tex.ID != gls.bound {
+		gl.BindTexture(gl.TEXTURE_2D, tex.ID)
+		gls.bound = tex.ID
+	}
 	gl.BindVertexArray(gls.vao)
 	gl.DrawArrays(method, 0, int32(gls.meshSize))
 }
+
+// reset forgets the bound texture, call it after other textures were bound.
+func (gls *glSpriter) reset() {
+	gls.bound = 0
+}
@@ -1,6 +1,7 @@
 package opengl
 
 import (
+	"fmt"
 	"image"
 
 	"github.com/go-gl/gl/v2.1/gl"
@@ -8,10 +9,19 @@ import (
 )
 
 type glTexture struct {
-	image graphics.Image
-	ID    uint32
+	image   graphics.Image
+	ID      uint32
+	indexed bool
+	// uvRect offset and size of the image in its texture, atlas regions are only a part of it
+	uvRect [4]float32
 }
 
+// fullUV the image covers the whole texture
+var fullUV = [4]float32{0, 0, 1, 1}
+
+// atlasPageSize width and height of the atlas pages for sprites, fonts and HUD graphics
+const atlasPageSize = 2048
+
 type glTextureStore map[string][]*glTexture
 
 func newGLTextureStore() glTextureStore {
@@ -25,8 +35,8 @@ func (ts glTextureStore) initTexture(name string, count int) {
 	ts[name] = make([]*glTexture, count)
 }
 
-func (ts glTextureStore) addTexture(name string, idx int, img graphics.Image) *glTexture {
-	var tex = makeGLTexture(img)
+func (ts glTextureStore) addTexture(name string, idx int, img graphics.Image, indexed bool) *glTexture {
+	var tex = makeGLTexture(img, indexed)
 	ts[name][idx] = tex
 	return tex
 }
@@ -41,18 +51,79 @@ func (ts glTextureStore) Get(name string, idx int) *glTexture {
 	return ts["null"][0]
 }
 
-func makeGLTexture(img graphics.Image) *glTexture {
+// makeGLTexture uploads an image, either baked to RGBA with the default
+// palette or as palette indices to be resolved by the shader.
+// High resolution replacements are preferred for RGBA.
+func makeGLTexture(img graphics.Image, indexed bool) *glTexture {
 	if img == nil {
 		return nil
 	}
-	tex := img.ToRGBA(graphics.DefaultPalette().Colors)
+	if indexed {
+		return &glTexture{
+			ID:      genGLIndexedTexture(img.ToPaletted(graphics.DefaultPalette().Colors)),
+			image:   img,
+			indexed: true,
+			uvRect:  fullUV,
+		}
+	}
+	tex := graphics.TrueColor(img, graphics.DefaultPalette().Colors)
 
 	return &glTexture{
-		ID:    genGLTexture(tex),
-		image: img,
+		ID:     genGLTexture(tex),
+		image:  img,
+		uvRect: fullUV,
+	}
+}
+
+// atlasSource is an image to pack into the atlas, stored as texture idx of name.
+type atlasSource struct {
+	name string
+	idx  int
+	img  graphics.Image
+}
+
+// addAtlas packs the images into atlas pages, uploads the pages and adds
+// the images as regions of them.
+func (ts glTextureStore) addAtlas(sources []atlasSource, indexed bool) {
+	builder := graphics.NewAtlasBuilder(atlasPageSize, indexed, graphics.DefaultPalette())
+	for _, src := range sources {
+		builder.Add(atlasKey(src.name, src.idx), src.img)
+	}
+	var (
+		atlas = builder.Build()
+		ids   = make([]uint32, len(atlas.Pages))
+	)
+	for i, page := range atlas.Pages {
+		if indexed {
+			ids[i] = genGLIndexedTexture(page.Indexed)
+		} else {
+			ids[i] = genGLTexture(page.RGBA)
+		}
+	}
+	for _, src := range sources {
+		if _, ok := ts[src.name]; !ok {
+			ts.initTexture(src.name, src.idx+1)
+		}
+		for len(ts[src.name]) <= src.idx {
+			ts[src.name] = append(ts[src.name], nil)
+		}
+		region, ok := atlas.Region(atlasKey(src.name, src.idx))
+		if !ok {
+			continue
+		}
+		ts[src.name][src.idx] = &glTexture{
+			image:   src.img,
+			ID:      ids[region.Page],
+			indexed: indexed,
+			uvRect:  [4]float32{region.U0, region.V0, region.U1 - region.U0, region.V1 - region.V0},
+		}
 	}
 }
 
+func atlasKey(name string, idx int) string {
+	return fmt.Sprintf("%s/%d", name, idx)
+}
+
 func makeNoTexture() *glTexture {
 	tex := image.NewRGBA(image.Rect(0, 0, 64, 64))
 	return &glTexture{
@@ -83,3 +154,93 @@ func genGLTexture(tex *image.RGBA) uint32 {
 	)
 	return texID
 }
+
+// genGLIndexedTexture uploads palette indices into a single channel texture.
+// Indices must not be filtered, so both filters are NEAREST.
+func genGLIndexedTexture(tex *image.Paletted) uint32 {
+	var texID uint32
+	gl.GenTextures(1, &texID)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, texID)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
+	genGLLookupTexture(tex.Pix, tex.Rect.Size().X, tex.Rect.Size().Y, gl.RED)
+	return texID
+}
+
+// updateGLPalette replaces the colors of the currently bound palette texture.
+func updateGLPalette(palette graphics.Palette) {
+	genGLLookupTexture(palettePix(palette), 256, 1, gl.RGBA)
+}
+
+func palettePix(palette graphics.Palette) []uint8 {
+	pix := make([]uint8, 0, 256*4)
+	for _, c := range palette.Colors {
+		pix = append(pix, c.R, c.G, c.B, 255)
+	}
+	return pix
+}
+
+// genGLLookupTexture uploads unfiltered bytes to the currently bound texture.
+func genGLLookupTexture(pix []uint8, width, height int, format uint32) {
+	var internal int32 = gl.R8
+	if format == gl.RGBA {
+		internal = gl.RGBA8
+	}
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.TexImage2D(
+		gl.TEXTURE_2D,
+		0,
+		internal,
+		int32(width),
+		int32(height),
+		0,
+		format,
+		gl.UNSIGNED_BYTE,
+		gl.Ptr(pix),
+	)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+}
+
+// genGLColorTables uploads palette and colormap as lookup textures.
+func genGLColorTables(palette graphics.Palette, colormap *graphics.Colormap) (paletteID, colormapID uint32) {
+	var (
+		palPix = palettePix(palette)
+		cmPix  = make([]uint8, 0, graphics.NumColormaps*256)
+	)
+	for i := range colormap {
+		cmPix = append(cmPix, colormap[i][:]...)
+	}
+
+	paletteID = genGLLookupTable(palPix, 256, 1, gl.RGBA)
+	colormapID = genGLLookupTable(cmPix, 256, graphics.NumColormaps, gl.RED)
+	return paletteID, colormapID
+}
+
+// genGLFuzzTable uploads the row offsets of the fuzz effect, 0 for the row above and 255 below.
+func genGLFuzzTable() uint32 {
+	var pix []uint8
+	for _, o := range graphics.FuzzOffsets() {
+		if o < 0 {
+			pix = append(pix, 0)
+		} else {
+			pix = append(pix, 255)
+		}
+	}
+	return genGLLookupTable(pix, len(pix), 1, gl.RED)
+}
+
+// genGLLookupTable creates an unfiltered lookup texture of the bytes.
+func genGLLookupTable(pix []uint8, width, height int, format uint32) uint32 {
+	var id uint32
+	gl.GenTextures(1, &id)
+	gl.BindTexture(gl.TEXTURE_2D, id)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	genGLLookupTexture(pix, width, height, format)
+	return id
+}
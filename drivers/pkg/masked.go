@@ -0,0 +1,60 @@
+package pkg
+
+import (
+	"sort"
+
+	"github.com/tinogoehlert/goom/utils"
+)
+
+// masked is a see-through wall or sprite waiting for the masked pass.
+type masked struct {
+	dist float32
+	draw func()
+}
+
+// MaskedPass collects the masked walls and sprites of a frame. They are drawn
+// back to front after the opaque geometry, so transparent pixels and
+// translucent sprites show what is behind them.
+type MaskedPass struct {
+	items []masked
+}
+
+// Add queues a draw at the distance dist from the camera.
+func (mp *MaskedPass) Add(dist float32, draw func()) {
+	mp.items = append(mp.items, masked{dist: dist, draw: draw})
+}
+
+// Len gets the number of queued draws.
+func (mp *MaskedPass) Len() int {
+	return len(mp.items)
+}
+
+// Flush draws the queued draws from the farthest to the nearest and empties the pass,
+// draws at the same distance keep the order they were added in.
+func (mp *MaskedPass) Flush() {
+	sort.SliceStable(mp.items, func(i, j int) bool {
+		return mp.items[i].dist > mp.items[j].dist
+	})
+	for _, m := range mp.items {
+		m.draw()
+	}
+	mp.Reset()
+}
+
+// Reset drops the queued draws.
+func (mp *MaskedPass) Reset() {
+	mp.items = mp.items[:0]
+}
+
+// Distance gets the distance of p to the nearest point of the wall on the map.
+func (wq *WallQuad) Distance(p utils.Vec2) float32 {
+	var (
+		line = wq.End.Sub(wq.Start)
+		ls   = line.Dot(line)
+	)
+	if ls == 0 {
+		return p.DistanceTo(wq.Start)
+	}
+	t := utils.Clamp(p.Sub(wq.Start).Dot(line)/ls, 0, 1)
+	return p.DistanceTo(wq.Start.Add(line.Scale(t)))
+}
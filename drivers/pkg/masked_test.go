@@ -0,0 +1,34 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/tinogoehlert/goom/test"
+	"github.com/tinogoehlert/goom/utils"
+)
+
+func TestMaskedPass(t *testing.T) {
+	var (
+		mp    MaskedPass
+		drawn []string
+	)
+	add := func(name string, dist float32) {
+		mp.Add(dist, func() { drawn = append(drawn, name) })
+	}
+	add("near", 10)
+	add("far", 300)
+	add("first", 100)
+	add("second", 100)
+	test.Assert(mp.Len() == 4, "expected the queued draws", t)
+	mp.Flush()
+	test.Assert(len(drawn) == 4 && drawn[0] == "far" && drawn[1] == "first" && drawn[2] == "second" && drawn[3] == "near",
+		"expected the draws back to front", t)
+	test.Assert(mp.Len() == 0, "flush must empty the pass", t)
+}
+
+func TestWallDistance(t *testing.T) {
+	wq := WallQuad{Start: utils.V2(0, 0), End: utils.V2(100, 0)}
+	test.Assert(wq.Distance(utils.V2(50, 30)) == 30, "expected the distance to the wall", t)
+	test.Assert(wq.Distance(utils.V2(-40, 30)) == 50, "expected the distance to the start", t)
+	test.Assert(wq.Distance(utils.V2(130, 40)) == 50, "expected the distance to the end", t)
+}
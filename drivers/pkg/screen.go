@@ -0,0 +1,7 @@
+package pkg
+
+// size of the original screen, graphics are positioned in its coordinates
+const (
+	ScreenWidth  = 320
+	ScreenHeight = 200
+)
@@ -0,0 +1,36 @@
+package pkg
+
+import "github.com/tinogoehlert/goom/level"
+
+// SectorSubSectors maps the sectors to the subsectors whose flats or walls
+// depend on their heights, which must be rebuilt when the sector moves.
+func SectorSubSectors(md *level.Level) map[int][]int {
+	var (
+		dependents = make(map[int][]int)
+		ssects     = md.BspSubSectors()
+	)
+	for i := range ssects {
+		seen := make(map[int]bool)
+		add := func(sector int) {
+			if !seen[sector] {
+				seen[sector] = true
+				dependents[sector] = append(dependents[sector], i)
+			}
+		}
+		if sector := md.SectorFromSSect(&ssects[i]); sector != nil {
+			add(sector.Index())
+		}
+		for _, seg := range ssects[i].Segments() {
+			if seg.LineDef() < 0 {
+				continue
+			}
+			line := &md.LinesDefs[seg.LineDef()]
+			for _, side := range []int16{line.Right, line.Left} {
+				if side >= 0 {
+					add(int(md.SideDefs[side].Sector))
+				}
+			}
+		}
+	}
+	return dependents
+}
@@ -0,0 +1,48 @@
+package pkg
+
+// SpriteQuad is a sprite placed in map units, one texel of the picture is one map unit.
+type SpriteQuad struct {
+	// Left and Right along the view's right axis, relative to the thing's position
+	Left, Right float32
+	// Top and Bottom heights of the visible part
+	Top, Bottom float32
+	// VBottom texture row at the bottom in texture heights, less than 1 if clipped by the floor
+	VBottom float32
+}
+
+// Width gets the width of the quad.
+func (sq *SpriteQuad) Width() float32 {
+	return sq.Right - sq.Left
+}
+
+// Height gets the height of the visible part of the quad.
+func (sq *SpriteQuad) Height() float32 {
+	return sq.Top - sq.Bottom
+}
+
+// Flipped gets the quad of the mirrored picture, its left offset is measured from the right.
+func (sq SpriteQuad) Flipped() SpriteQuad {
+	sq.Left, sq.Right = -sq.Right, -sq.Left
+	return sq
+}
+
+// PlaceSprite places a picture of width x height texels like vanilla's R_ProjectSprite,
+// its left offset is left of the thing and its top offset above the thing's height z.
+// The rows below the floor are clipped, ok is false if nothing is left.
+func PlaceSprite(z, floor float32, width, height, left, top int) (sq SpriteQuad, ok bool) {
+	sq = SpriteQuad{
+		Left:    float32(-left),
+		Right:   float32(width - left),
+		Top:     z + float32(top),
+		VBottom: 1,
+	}
+	sq.Bottom = sq.Top - float32(height)
+	if sq.Top <= floor || height <= 0 || width <= 0 {
+		return sq, false
+	}
+	if sq.Bottom < floor {
+		sq.VBottom = (sq.Top - floor) / float32(height)
+		sq.Bottom = floor
+	}
+	return sq, true
+}
@@ -0,0 +1,27 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/tinogoehlert/goom/test"
+)
+
+func TestPlaceSprite(t *testing.T) {
+	// a 40x60 picture with its origin at the bottom center
+	sq, ok := PlaceSprite(16, 16, 40, 60, 20, 60)
+	test.Assert(ok, "expected a visible sprite", t)
+	test.Assert(sq.Left == -20 && sq.Right == 20 && sq.Width() == 40, "expected the picture centered on the thing", t)
+	test.Assert(sq.Top == 76 && sq.Bottom == 16 && sq.VBottom == 1, "expected the picture standing on the floor", t)
+
+	sq, _ = PlaceSprite(0, 0, 40, 60, 10, 60)
+	sq = sq.Flipped()
+	test.Assert(sq.Left == -30 && sq.Right == 10, "expected the offset mirrored", t)
+
+	// a corpse reaching 10 units into the floor
+	sq, ok = PlaceSprite(0, 0, 40, 20, 20, 10)
+	test.Assert(ok, "expected a visible sprite", t)
+	test.Assert(sq.Bottom == 0 && sq.Height() == 10 && sq.VBottom == 0.5, "expected the rows below the floor clipped", t)
+
+	_, ok = PlaceSprite(0, 0, 40, 20, 20, 0)
+	test.Assert(!ok, "a sprite below the floor must be hidden", t)
+}
@@ -0,0 +1,210 @@
+package pkg
+
+import (
+	"github.com/tinogoehlert/goom/graphics"
+	"github.com/tinogoehlert/goom/level"
+	"github.com/tinogoehlert/goom/utils"
+)
+
+// SkyFlatName is the ceiling flat marking the sky.
+const SkyFlatName = "F_SKY1"
+
+// WallQuad is a vertical, textured quad of a wall in map coordinates.
+// It is shared by all renderers so walls look the same on every backend.
+type WallQuad struct {
+	Texture string
+	Start   utils.Vec2
+	End     utils.Vec2
+	Bottom  float32
+	Top     float32
+	// texture coordinates in texture sizes, U along the wall and V from top to bottom
+	U0      float32
+	U1      float32
+	VTop    float32
+	VBottom float32
+	Light   float32
+	IsSky   bool
+	// Masked middle textures of two sided lines are see-through, they are drawn in the masked pass
+	Masked bool
+	// Side and Part point to the texture on the sidedef, which may change at runtime
+	Side *level.SideDef
+	Part level.SidePart
+	// Sector in front of the wall, its light may change at runtime
+	Sector *level.Sector
+}
+
+// TextureName gets the current texture of the quad, switches change it at runtime.
+func (wq *WallQuad) TextureName() string {
+	if wq.Side == nil {
+		return wq.Texture
+	}
+	return wq.Side.Texture(wq.Part)
+}
+
+// LightLevel gets the current light of the quad's sector.
+func (wq *WallQuad) LightLevel() float32 {
+	if wq.Sector == nil {
+		return wq.Light
+	}
+	return wq.Sector.LightLevel()
+}
+
+// heights of the floor and ceiling of a sector
+type heights struct {
+	floor, ceil float32
+}
+
+func sectorHeights(s *level.Sector, frac float32) heights {
+	return heights{s.LerpFloorHeight(frac), s.LerpCeilHeight(frac)}
+}
+
+// textureTop gets the height the top row of a part's texture is pegged to,
+// before the row offset of the sidedef, like vanilla's R_StoreWallRange.
+// back is nil for one sided lines.
+func textureTop(part level.SidePart, flags int16, front heights, back *heights, texHeight float32) float32 {
+	var (
+		upperUnpegged = flags&level.LineUpperUnpegged != 0
+		lowerUnpegged = flags&level.LineLowerUnpegged != 0
+	)
+	switch {
+	case back == nil:
+		if lowerUnpegged {
+			return front.floor + texHeight
+		}
+		return front.ceil
+	case part == level.SideUpper:
+		if upperUnpegged {
+			return front.ceil
+		}
+		return back.ceil + texHeight
+	case part == level.SideLower:
+		if lowerUnpegged {
+			return front.ceil
+		}
+		return back.floor
+	default:
+		// middle textures of two sided lines are drawn once in the opening
+		if lowerUnpegged {
+			return utils.Max(front.floor, back.floor) + texHeight
+		}
+		return utils.Min(front.ceil, back.ceil)
+	}
+}
+
+// clampMiddle clamps the middle texture of a two sided line to the rows of the
+// texture, it does not repeat vertically. ok is false if nothing is visible.
+func clampMiddle(top, bottom, texTop, texHeight float32) (float32, float32, bool) {
+	top = utils.Min(top, texTop)
+	bottom = utils.Max(bottom, texTop-texHeight)
+	return top, bottom, top > bottom
+}
+
+// SubSectorWalls builds the upper, lower and middle wall quads of the segs of a
+// subsector, seen from the sidedef in front of the seg. The textures are aligned
+// by the sidedef offsets, the offset of the seg along the line and the unpegged flags.
+// The heights of moving sectors are interpolated by frac between the last and the current tic.
+func SubSectorWalls(md *level.Level, ssect *level.SubSector, textures graphics.TextureStore, frac float32) []WallQuad {
+	var walls []WallQuad
+	for _, seg := range ssect.Segments() {
+		if seg.LineDef() == -1 {
+			continue
+		}
+		var (
+			line   = &md.LinesDefs[seg.LineDef()]
+			side   = &md.SideDefs[line.Right]
+			origin = md.Vert(uint32(uint16(line.Start)))
+			back   *heights
+		)
+		if seg.Direction() != 0 {
+			if line.Left == -1 {
+				continue
+			}
+			side = &md.SideDefs[line.Left]
+			origin = md.Vert(uint32(uint16(line.End)))
+		}
+		otherSide := md.OtherSide(line, seg)
+		sector := &md.Sectors[side.Sector]
+		front := sectorHeights(sector, frac)
+		if otherSide != nil && otherSide != side {
+			h := sectorHeights(&md.Sectors[otherSide.Sector], frac)
+			back = &h
+		}
+
+		var (
+			start = md.Vert(seg.StartVert())
+			end   = md.Vert(seg.EndVert())
+			// texture column at the start of the seg
+			column = float32(side.X) + origin.DistanceTo(start)
+			length = start.DistanceTo(end)
+		)
+		quad := func(part level.SidePart, bottom, top float32) (WallQuad, bool) {
+			name := side.Texture(part)
+			tex, ok := textures[name]
+			if !ok || top <= bottom {
+				return WallQuad{}, false
+			}
+			var (
+				tw     = float32(tex.Width())
+				th     = float32(tex.Height())
+				texTop = textureTop(part, line.Flags, front, back, th) + float32(side.Y)
+			)
+			if part == level.SideMiddle && back != nil {
+				if top, bottom, ok = clampMiddle(top, bottom, texTop, th); !ok {
+					return WallQuad{}, false
+				}
+			}
+			return WallQuad{
+				Texture: name,
+				Start:   start,
+				End:     end,
+				Bottom:  bottom,
+				Top:     top,
+				U0:      column / tw,
+				U1:      (column + length) / tw,
+				VTop:    (texTop - top) / th,
+				VBottom: (texTop - bottom) / th,
+				Light:   sector.LightLevel(),
+				IsSky:   name == SkyFlatName,
+				Masked:  part == level.SideMiddle && back != nil,
+				Side:    side,
+				Part:    part,
+				Sector:  sector,
+			}, true
+		}
+
+		if back == nil {
+			if wq, ok := quad(level.SideMiddle, front.floor, front.ceil); ok {
+				walls = append(walls, wq)
+			}
+			continue
+		}
+
+		if sector.CeilTexture() == SkyFlatName && md.Sectors[otherSide.Sector].CeilTexture() == SkyFlatName {
+			// the sky hides the upper wall between two sky ceilings,
+			// it is drawn even without texture to not leave a gap
+			if back.ceil < front.ceil {
+				walls = append(walls, WallQuad{
+					Texture: side.Upper(),
+					Start:   start,
+					End:     end,
+					Bottom:  back.ceil,
+					Top:     front.ceil,
+					Light:   sector.LightLevel(),
+					IsSky:   true,
+					Side:    side,
+					Part:    level.SideUpper,
+					Sector:  sector,
+				})
+			}
+		} else if wq, ok := quad(level.SideUpper, back.ceil, front.ceil); ok {
+			walls = append(walls, wq)
+		}
+		if wq, ok := quad(level.SideLower, front.floor, back.floor); ok {
+			walls = append(walls, wq)
+		}
+		if wq, ok := quad(level.SideMiddle, utils.Max(front.floor, back.floor), utils.Min(front.ceil, back.ceil)); ok {
+			walls = append(walls, wq)
+		}
+	}
+	return walls
+}
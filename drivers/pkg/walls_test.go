@@ -0,0 +1,43 @@
+package pkg
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tinogoehlert/goom/level"
+	"github.com/tinogoehlert/goom/test"
+)
+
+func TestTextureTop(t *testing.T) {
+	var (
+		front = heights{floor: 0, ceil: 128}
+		back  = heights{floor: 24, ceil: 96}
+	)
+	for _, c := range []struct {
+		part  level.SidePart
+		flags int16
+		back  *heights
+		top   float32
+	}{
+		{level.SideMiddle, 0, nil, 128},
+		{level.SideMiddle, level.LineLowerUnpegged, nil, 64},
+		{level.SideUpper, 0, &back, 160},
+		{level.SideUpper, level.LineUpperUnpegged, &back, 128},
+		{level.SideLower, 0, &back, 24},
+		{level.SideLower, level.LineLowerUnpegged, &back, 128},
+		{level.SideMiddle, 0, &back, 96},
+		{level.SideMiddle, level.LineLowerUnpegged, &back, 88},
+	} {
+		top := textureTop(c.part, c.flags, front, c.back, 64)
+		test.Assert(top == c.top, fmt.Sprintf("part %d flags %d: expected %v, got %v", c.part, c.flags, c.top, top), t)
+	}
+}
+
+func TestClampMiddle(t *testing.T) {
+	top, bottom, ok := clampMiddle(96, 24, 96, 32)
+	test.Assert(ok && top == 96 && bottom == 64, "expected the texture height below its top", t)
+	top, bottom, ok = clampMiddle(96, 24, 200, 128)
+	test.Assert(ok && top == 96 && bottom == 72, "expected the opening to clip the texture", t)
+	_, _, ok = clampMiddle(96, 24, 10, 8)
+	test.Assert(!ok, "expected a texture below the opening to be hidden", t)
+}
@@ -1,11 +1,95 @@
 package drivers
 
 import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+
+	"github.com/tinogoehlert/goom/debug"
+	"github.com/tinogoehlert/goom/game"
 	"github.com/tinogoehlert/goom/goom"
+	"github.com/tinogoehlert/goom/graphics"
 	"github.com/tinogoehlert/goom/level"
 )
 
 // Renderer interface for the DOOM engine
 type Renderer interface {
+	graphics.GraphicDrawer
 	LoadLevel(m *level.Level, gd *goom.GameData)
+	// SetViewPort sets the size of the framebuffer.
+	SetViewPort(width, height int)
+	// SetCamera sets the map position, the view direction and the eye height.
+	SetCamera(pos [2]float32, dir [3]float32, height float32)
+	// Frustum gets the view frustum on the map, nil if it can not be culled by.
+	Frustum() *level.Frustum
+	SetLevelTime(tic int)
+	// SetInterpolation sets the fraction of the time between the last and the next
+	// game tic, moving things, weapon bob and sectors are drawn interpolated by it.
+	SetInterpolation(frac float32)
+	SetPalette(index int)
+	SetGamma(level int)
+	SetInvulnerability(enabled bool)
+	// RenderNewFrame starts a frame, followed by the draw calls.
+	RenderNewFrame()
+	DrawSubSector(idx int)
+	// DrawThings draws the things and the see-through walls of the drawn
+	// subsectors back to front, after the opaque geometry.
+	DrawThings(things []game.Thingable)
+	DrawHUD(player *game.Player, t float64)
+	DrawHUdElement(name string, xpos, ypos float32, scaleFactor float32)
+	// DrawOverlay draws the lines of the debug overlay on top of the frame.
+	DrawOverlay(o *debug.Overlay)
+	// FinishFrame ends a frame, GPU renderers scale their offscreen frame onto the window.
+	FinishFrame()
+	// Screenshot reads the last frame back at the internal resolution.
+	Screenshot() (*image.RGBA, error)
+}
+
+// FrameRenderer is a renderer drawing on the CPU, its frames are shown by a FramePresenter.
+type FrameRenderer interface {
+	Renderer
+	Frame() *image.RGBA
+}
+
+// RendererOptions configures a renderer.
+type RendererOptions struct {
+	// Width and Height of the framebuffer until the first SetViewPort
+	Width, Height int
+	// IndexedColor uses the COLORMAP for banded DOOM lighting
+	IndexedColor bool
+	// ShaderDir directory of the shaders of GPU renderers
+	ShaderDir string
+	// InternalWidth and InternalHeight of the frames scaled to the window,
+	// e.g. 320x200 for Doom's look. Zero renders at the window size.
+	InternalWidth, InternalHeight int
+	// Offscreen renders without showing a window, GPU renderers need
+	// a window driver that is an OffscreenWindow.
+	Offscreen bool
+}
+
+// RendererFactory creates the renderer of a backend.
+type RendererFactory struct {
+	// Software renderers draw frames on the CPU, their window must be
+	// a FramePresenter opened without GL context.
+	Software bool
+	// New creates the renderer after the window was opened.
+	New func(gd *goom.GameData, opts RendererOptions) (Renderer, error)
+}
+
+// SaveScreenshot writes the last frame of the renderer to a PNG file.
+func SaveScreenshot(r Renderer, file string) error {
+	img, err := r.Screenshot()
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("could not create %s: %s", file, err.Error())
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		return fmt.Errorf("could not encode %s: %s", file, err.Error())
+	}
+	return nil
 }
@@ -98,6 +98,11 @@ var sdlDriversKeyMap = map[shared.Keycode]sdl.Keycode{
 	shared.KeyF6:     sdl.K_F6,
 	shared.KeyF7:     sdl.K_F7,
 	shared.KeyF8:     sdl.K_F8,
+	shared.KeyF9:     sdl.K_F9,
+	shared.KeyF11:    sdl.K_F11,
+	shared.KeyF12:    sdl.K_F12,
+	shared.KeyMinus:  sdl.K_MINUS,
+	shared.KeyEquals: sdl.K_EQUALS,
 }
 
 var sdlMouseButtonMap = map[shared.MouseButton]uint32{
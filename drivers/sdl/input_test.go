@@ -0,0 +1,23 @@
+package sdl
+
+import (
+	"fmt"
+	"testing"
+
+	shared "github.com/tinogoehlert/goom/drivers/pkg"
+	"github.com/tinogoehlert/goom/test"
+)
+
+func TestGameKeysMapped(t *testing.T) {
+	// keys the game reads in main.go, unmapped keys are never pressed
+	for _, k := range []shared.Keycode{
+		shared.KeyW, shared.KeyA, shared.KeyS, shared.KeyD,
+		shared.KeyUp, shared.KeyDown, shared.KeyLeft, shared.KeyRight,
+		shared.KeyLShift, shared.KeyQ, shared.KeyM, shared.KeyK,
+		shared.KeyF7, shared.KeyF8, shared.KeyF9, shared.KeyF11, shared.KeyF12,
+		shared.KeyMinus, shared.KeyEquals,
+	} {
+		_, ok := sdlDriversKeyMap[k]
+		test.Assert(ok, fmt.Sprintf("key %d is not mapped", k), t)
+	}
+}
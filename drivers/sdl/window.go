@@ -1,7 +1,10 @@
 package sdl
 
 import (
+	"fmt"
+	"image"
 	"log"
+	"unsafe"
 
 	"github.com/tinogoehlert/go-sdl2/sdl"
 )
@@ -18,6 +21,20 @@ type Window struct {
 	fbSizeChanged      func(width int, height int)
 	shouldClose        bool
 	mouseCameraEnabled bool
+	software           bool
+	offscreen          bool
+}
+
+// SetSoftware opens the window without GL context to present frames rendered
+// on the CPU, it must be called before Open.
+func (w *Window) SetSoftware(enabled bool) {
+	w.software = enabled
+}
+
+// SetOffscreen creates the window hidden, its GL context renders into
+// offscreen framebuffers. It must be called before Open.
+func (w *Window) SetOffscreen(enabled bool) {
+	w.offscreen = enabled
 }
 
 // Open inits a new SQL window with GL context.
@@ -31,19 +48,34 @@ func (w *Window) Open(title string, width, height int) error {
 		return err
 	}
 
+	var flags uint32 = sdl.WINDOW_RESIZABLE | sdl.WINDOW_ALLOW_HIGHDPI
+	if !w.software {
+		flags |= sdl.WINDOW_OPENGL
+	}
+	if w.offscreen {
+		flags |= sdl.WINDOW_HIDDEN
+	}
+
 	sdlwin, err := sdl.CreateWindow(
 		title,
 		sdl.WINDOWPOS_UNDEFINED,
 		sdl.WINDOWPOS_UNDEFINED,
 		int32(width),
 		int32(height),
-		sdl.WINDOW_OPENGL|sdl.WINDOW_RESIZABLE|sdl.WINDOW_ALLOW_HIGHDPI,
+		flags,
 	)
 	if err != nil {
 		log.Println(err)
 		return err
 	}
 
+	if w.software {
+		w.window = sdlwin
+		w.fbWidth = width
+		w.fbHeight = height
+		return nil
+	}
+
 	sdl.GLSetAttribute(sdl.GL_DOUBLEBUFFER, 2)
 	sdl.GLSetAttribute(sdl.GL_DEPTH_SIZE, 32)
 	sdl.GLSetAttribute(sdl.GL_CONTEXT_PROFILE_MASK, sdl.GL_CONTEXT_PROFILE_CORE)
@@ -96,14 +128,45 @@ func (w *Window) RunGame(input func(), update func(), render func(float64)) {
 			input()
 			update()
 		}
-		// TODO: This tells the renderer close we are to the next tick, so if we
-		//       are between two ticks we can display (as an example) the movement
-		//       of a projectile by and additional 0.8 units instead of fixed 1 unit.
-		//       The todo is to implement this in the renderer ^^.
+		// how close we are to the next tick, the renderer interpolates
+		// the moving things and sectors between the last and the next tick
 
 		render(lag / w.secsPerUpdate)
-		w.window.GLSwap()
+		if !w.software {
+			w.window.GLSwap()
+		}
+	}
+}
+
+// PresentFrame copies a frame rendered on the CPU scaled onto the window.
+func (w *Window) PresentFrame(frame *image.RGBA) error {
+	if !w.software {
+		return fmt.Errorf("could not present frame: window has a GL context")
+	}
+	if len(frame.Pix) == 0 {
+		return nil
+	}
+	src, err := sdl.CreateRGBSurfaceWithFormatFrom(
+		unsafe.Pointer(&frame.Pix[0]),
+		int32(frame.Rect.Dx()),
+		int32(frame.Rect.Dy()),
+		32,
+		int32(frame.Stride),
+		uint32(sdl.PIXELFORMAT_ABGR8888),
+	)
+	if err != nil {
+		return fmt.Errorf("could not create frame surface: %s", err.Error())
+	}
+	defer src.Free()
+
+	dst, err := w.window.GetSurface()
+	if err != nil {
+		return fmt.Errorf("could not get window surface: %s", err.Error())
+	}
+	if err := src.BlitScaled(nil, dst, nil); err != nil {
+		return fmt.Errorf("could not blit frame: %s", err.Error())
 	}
+	return w.window.UpdateSurface()
 }
 
 // Close closes the window
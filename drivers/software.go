@@ -0,0 +1,19 @@
+package drivers
+
+import (
+	"github.com/tinogoehlert/goom/drivers/software"
+	"github.com/tinogoehlert/goom/goom"
+)
+
+func init() {
+	RendererDrivers[SoftwareRenderer] = RendererFactory{
+		Software: true,
+		New: func(gd *goom.GameData, opts RendererOptions) (Renderer, error) {
+			r, err := software.NewRenderer(gd, opts.Width, opts.Height)
+			if err == nil && opts.InternalWidth > 0 && opts.InternalHeight > 0 {
+				r.SetResolution(opts.InternalWidth, opts.InternalHeight)
+			}
+			return r, err
+		},
+	}
+}
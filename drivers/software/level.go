@@ -0,0 +1,91 @@
+package software
+
+import (
+	"github.com/tinogoehlert/goom/drivers/pkg"
+	"github.com/tinogoehlert/goom/goom"
+	"github.com/tinogoehlert/goom/level"
+	"github.com/tinogoehlert/goom/utils"
+)
+
+type doomLevel struct {
+	name       string
+	subSectors []*subSector
+	mapRef     *level.Level
+	gd         *goom.GameData
+	// dependents subsectors of the sectors, dirty ones rebuild their walls
+	dependents     map[int][]int
+	dirty          map[int]bool
+	removeListener func()
+	// moving sectors and the fraction of the tic their heights are interpolated with
+	moving map[int]bool
+	frac   float32
+}
+
+type subSector struct {
+	polygon []utils.Vec2
+	sector  *level.Sector
+	walls   []pkg.WallQuad
+}
+
+func registerMap(m *level.Level, gd *goom.GameData) *doomLevel {
+	l := doomLevel{
+		name:       m.Name,
+		mapRef:     m,
+		gd:         gd,
+		subSectors: make([]*subSector, 0, len(m.BspSubSectors())),
+		dependents: pkg.SectorSubSectors(m),
+		dirty:      make(map[int]bool),
+		moving:     make(map[int]bool),
+		frac:       1,
+	}
+	ssects := m.BspSubSectors()
+	for i := range ssects {
+		l.subSectors = append(l.subSectors, &subSector{
+			polygon: m.SubSectorPolygon(i),
+			sector:  m.SectorFromSSect(&ssects[i]),
+			walls:   pkg.SubSectorWalls(m, &ssects[i], gd.Textures, l.frac),
+		})
+	}
+	l.removeListener = m.OnSectorChange(l.sectorChanged)
+	return &l
+}
+
+// sectorChanged marks the subsectors with walls along a moved sector.
+func (l *doomLevel) sectorChanged(sector int, change level.SectorChange) {
+	if change&(level.SectorFloorChanged|level.SectorCeilingChanged) == 0 {
+		return
+	}
+	l.moving[sector] = true
+	for _, idx := range l.dependents[sector] {
+		l.dirty[idx] = true
+	}
+}
+
+// interpolate marks the subsectors of moving sectors to rebuild their walls
+// at the heights interpolated by frac, until the sectors stopped moving.
+func (l *doomLevel) interpolate(frac float32) {
+	l.frac = frac
+	for sector := range l.moving {
+		if !l.mapRef.Sectors[sector].Moving() {
+			delete(l.moving, sector)
+		}
+		for _, idx := range l.dependents[sector] {
+			l.dirty[idx] = true
+		}
+	}
+}
+
+// update rebuilds the walls of a moved subsector, the flats use the sector heights.
+func (l *doomLevel) update(idx int) {
+	if !l.dirty[idx] {
+		return
+	}
+	delete(l.dirty, idx)
+	ssects := l.mapRef.BspSubSectors()
+	l.subSectors[idx].walls = pkg.SubSectorWalls(l.mapRef, &ssects[idx], l.gd.Textures, l.frac)
+}
+
+// release stops listening to the sectors of the map.
+func (l *doomLevel) release() {
+	l.removeListener()
+}
@@ -0,0 +1,245 @@
+package software
+
+import (
+	"math"
+
+	"github.com/tinogoehlert/goom/game"
+	"github.com/tinogoehlert/goom/graphics"
+)
+
+// nearZ distance of the near clipping plane in map units
+const nearZ = 1
+
+// camVertex is a vertex in camera space, x points right, y up and z into the screen.
+type camVertex struct {
+	x, y, z float32
+	u, v    float32
+}
+
+// screenVertex is a projected vertex, the texture coordinates are divided
+// by depth so they can be interpolated linearly on screen.
+type screenVertex struct {
+	x, y   float32
+	iz     float32
+	uz, vz float32
+}
+
+// surface describes how the pixels of a polygon are colored.
+type surface struct {
+	tex   *texture
+	light float32
+	sky   bool
+	// style blends sprites with the pixels behind them, translucent
+	// and fuzzy pixels do not hide what is drawn behind them later
+	style game.RenderStyle
+	// bright pixels ignore the light, like fullbright frames of sprites
+	bright bool
+}
+
+// lightIndex gets the colormap of a pixel at the distance dist.
+func (s *surface) lightIndex(dist float32) int {
+	if s.bright {
+		return 0
+	}
+	return graphics.LightIndex(s.light, dist)
+}
+
+// clipNear cuts away the part of a polygon in front of the near plane.
+func clipNear(poly []camVertex) []camVertex {
+	out := make([]camVertex, 0, len(poly)+1)
+	for i := range poly {
+		var (
+			a, b   = poly[i], poly[(i+1)%len(poly)]
+			da, db = a.z - nearZ, b.z - nearZ
+		)
+		if da >= 0 {
+			out = append(out, a)
+		}
+		if (da >= 0) != (db >= 0) {
+			t := da / (da - db)
+			out = append(out, camVertex{
+				x: a.x + (b.x-a.x)*t,
+				y: a.y + (b.y-a.y)*t,
+				z: nearZ,
+				u: a.u + (b.u-a.u)*t,
+				v: a.v + (b.v-a.v)*t,
+			})
+		}
+	}
+	return out
+}
+
+func (r *Renderer) project(v camVertex) screenVertex {
+	iz := 1 / v.z
+	return screenVertex{
+		x:  r.centerX + v.x*iz*r.focal,
+		y:  r.centerY - v.y*iz*r.focal,
+		iz: iz,
+		uz: v.u * iz,
+		vz: v.v * iz,
+	}
+}
+
+// drawPolygon clips, projects and fills a convex polygon.
+func (r *Renderer) drawPolygon(poly []camVertex, s surface) {
+	if s.tex == nil && !s.sky {
+		return
+	}
+	poly = clipNear(poly)
+	if len(poly) < 3 {
+		return
+	}
+	sv := make([]screenVertex, len(poly))
+	for i, v := range poly {
+		sv[i] = r.project(v)
+	}
+	for i := 1; i+1 < len(sv); i++ {
+		r.fillTriangle(sv[0], sv[i], sv[i+1], s)
+	}
+}
+
+func edge(a, b screenVertex, x, y float32) float32 {
+	return (b.x-a.x)*(y-a.y) - (b.y-a.y)*(x-a.x)
+}
+
+// ownsEdge tells if a triangle of the given area draws the pixels exactly on its edge a, b.
+// The triangles sharing an edge run along it in opposite directions, so only one
+// of them draws the pixels and translucent pixels are not blended twice.
+func ownsEdge(a, b screenVertex, area float32) bool {
+	dx, dy := b.x-a.x, b.y-a.y
+	if area < 0 {
+		dx, dy = -dx, -dy
+	}
+	return dy > 0 || (dy == 0 && dx < 0)
+}
+
+// fillTriangle rasterizes a triangle with depth test and perspective correct texturing.
+func (r *Renderer) fillTriangle(a, b, c screenVertex, s surface) {
+	area := edge(a, b, c.x, c.y)
+	if area > -1e-6 && area < 1e-6 {
+		return
+	}
+	var (
+		minX = clampInt(int(math.Floor(float64(min3(a.x, b.x, c.x)))), 0, r.width-1)
+		maxX = clampInt(int(math.Ceil(float64(max3(a.x, b.x, c.x)))), 0, r.width-1)
+		minY = clampInt(int(math.Floor(float64(min3(a.y, b.y, c.y)))), 0, r.height-1)
+		maxY = clampInt(int(math.Ceil(float64(max3(a.y, b.y, c.y)))), 0, r.height-1)
+		inv  = 1 / area
+		// pixels exactly on an edge belong to one of the triangles sharing it
+		own0 = ownsEdge(b, c, area)
+		own1 = ownsEdge(c, a, area)
+		own2 = ownsEdge(a, b, area)
+	)
+	for y := minY; y <= maxY; y++ {
+		py := float32(y) + 0.5
+		for x := minX; x <= maxX; x++ {
+			px := float32(x) + 0.5
+			var (
+				w0 = edge(b, c, px, py) * inv
+				w1 = edge(c, a, px, py) * inv
+				w2 = edge(a, b, px, py) * inv
+			)
+			if w0 < 0 || w1 < 0 || w2 < 0 ||
+				(w0 == 0 && !own0) || (w1 == 0 && !own1) || (w2 == 0 && !own2) {
+				continue
+			}
+			var (
+				i  = y*r.width + x
+				iz = w0*a.iz + w1*b.iz + w2*c.iz
+			)
+			if iz <= r.depth[i] {
+				continue
+			}
+			var index uint8
+			if s.sky {
+				index = r.skyTexel(x, y)
+			} else {
+				var (
+					u  = (w0*a.uz + w1*b.uz + w2*c.uz) / iz
+					v  = (w0*a.vz + w1*b.vz + w2*c.vz) / iz
+					ok bool
+				)
+				if index, ok = s.tex.sample(u, v); !ok {
+					continue
+				}
+				switch s.style {
+				case game.StyleFuzz:
+					r.frame.Pix[i] = r.fuzzTexel(x, y)
+					continue
+				case game.StyleTranslucent:
+					index = r.shade(index, s.lightIndex(1/iz))
+					r.frame.Pix[i] = r.tranMap.Blend(index, r.frame.Pix[i])
+					continue
+				}
+				index = r.shade(index, s.lightIndex(1/iz))
+			}
+			r.frame.Pix[i] = index
+			r.depth[i] = iz
+		}
+	}
+}
+
+// fuzzTexel darkens the pixel above or below x, y like vanilla's fuzz effect.
+func (r *Renderer) fuzzTexel(x, y int) uint8 {
+	y = clampInt(y+r.fuzz.Next(), 0, r.height-1)
+	return r.shade(r.frame.Pix[y*r.width+x], graphics.FuzzColormap)
+}
+
+// blit draws a texture into the screen rectangle x0, y0 - x1, y1 without depth test.
+func (r *Renderer) blit(tex *texture, x0, y0, x1, y1 float32, cmap int) {
+	if tex == nil || x1 <= x0 || y1 <= y0 {
+		return
+	}
+	var (
+		minX = clampInt(int(math.Floor(float64(x0))), 0, r.width)
+		maxX = clampInt(int(math.Ceil(float64(x1))), 0, r.width)
+		minY = clampInt(int(math.Floor(float64(y0))), 0, r.height)
+		maxY = clampInt(int(math.Ceil(float64(y1))), 0, r.height)
+	)
+	for y := minY; y < maxY; y++ {
+		v := (float32(y) + 0.5 - y0) / (y1 - y0)
+		if v < 0 || v >= 1 {
+			continue
+		}
+		for x := minX; x < maxX; x++ {
+			u := (float32(x) + 0.5 - x0) / (x1 - x0)
+			if u < 0 || u >= 1 {
+				continue
+			}
+			index, ok := tex.sample(u, v)
+			if !ok {
+				continue
+			}
+			r.frame.Pix[y*r.width+x] = r.shade(index, cmap)
+		}
+	}
+}
+
+// shade maps a palette index through the colormap cmap, or the fixed colormap if one is set.
+func (r *Renderer) shade(index uint8, cmap int) uint8 {
+	if r.colormap == nil {
+		return index
+	}
+	if r.fixedColormap >= 0 {
+		cmap = r.fixedColormap
+	}
+	return r.colormap.Map(cmap)[index]
+}
+
+func min3(a, b, c float32) float32 {
+	return float32(math.Min(float64(a), math.Min(float64(b), float64(c))))
+}
+
+func max3(a, b, c float32) float32 {
+	return float32(math.Max(float64(a), math.Max(float64(b), float64(c))))
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,126 @@
+package software
+
+import (
+	"image"
+	"testing"
+
+	"github.com/tinogoehlert/goom/game"
+	"github.com/tinogoehlert/goom/graphics"
+	"github.com/tinogoehlert/goom/test"
+)
+
+func testRenderer(width, height int) *Renderer {
+	r := &Renderer{camera: NewCamera(), fixedColormap: -1}
+	r.camera.SetCamera([2]float32{0, 0}, [3]float32{0, 1, 0}, 0)
+	r.width, r.height = width, height
+	r.frame = image.NewPaletted(image.Rect(0, 0, width, height), nil)
+	r.depth = make([]float32, width*height)
+	r.updateView()
+	return r
+}
+
+func solidTexture(index uint8) *texture {
+	return &texture{width: 1, height: 1, pix: []uint8{index}}
+}
+
+// facingWall gets a wall dist units ahead covering the whole view.
+func facingWall(r *Renderer, dist float32) []camVertex {
+	return []camVertex{
+		r.toCamera(-500, dist, -500, 0, 1),
+		r.toCamera(-500, dist, 500, 0, 0),
+		r.toCamera(500, dist, 500, 1, 0),
+		r.toCamera(500, dist, -500, 1, 1),
+	}
+}
+
+func filled(r *Renderer, index uint8) int {
+	n := 0
+	for _, p := range r.frame.Pix {
+		if p == index {
+			n++
+		}
+	}
+	return n
+}
+
+func TestDrawWallFacingCamera(t *testing.T) {
+	r := testRenderer(64, 64)
+	// wall 100 units ahead covering the whole view
+	r.drawPolygon([]camVertex{
+		r.toCamera(-500, 100, -500, 0, 1),
+		r.toCamera(-500, 100, 500, 0, 0),
+		r.toCamera(500, 100, 500, 1, 0),
+		r.toCamera(500, 100, -500, 1, 1),
+	}, surface{tex: solidTexture(7)})
+	test.Assert(filled(r, 7) == 64*64, "expected wall to fill the screen", t)
+}
+
+func TestDepthTest(t *testing.T) {
+	r := testRenderer(64, 64)
+	near := []camVertex{
+		r.toCamera(-500, 100, -500, 0, 1),
+		r.toCamera(-500, 100, 500, 0, 0),
+		r.toCamera(500, 100, 500, 1, 0),
+		r.toCamera(500, 100, -500, 1, 1),
+	}
+	far := []camVertex{
+		r.toCamera(-5000, 1000, -5000, 0, 1),
+		r.toCamera(-5000, 1000, 5000, 0, 0),
+		r.toCamera(5000, 1000, 5000, 1, 0),
+		r.toCamera(5000, 1000, -5000, 1, 1),
+	}
+	r.drawPolygon(near, surface{tex: solidTexture(7)})
+	r.drawPolygon(far, surface{tex: solidTexture(9)})
+	test.Assert(filled(r, 9) == 0, "far wall must be hidden", t)
+}
+
+func TestClipBehindCamera(t *testing.T) {
+	r := testRenderer(64, 64)
+	r.drawPolygon([]camVertex{
+		r.toCamera(-50, -100, -50, 0, 1),
+		r.toCamera(-50, -100, 50, 0, 0),
+		r.toCamera(50, -100, 50, 1, 0),
+		r.toCamera(50, -100, -50, 1, 1),
+	}, surface{tex: solidTexture(7)})
+	test.Assert(filled(r, 7) == 0, "wall behind the camera must not be drawn", t)
+
+	// floor crossing the near plane covers the lower half only
+	r.drawPolygon([]camVertex{
+		r.toCamera(-1000, -1000, -10, 0, 0),
+		r.toCamera(-1000, 1000, -10, 0, 0),
+		r.toCamera(1000, 1000, -10, 0, 0),
+		r.toCamera(1000, -1000, -10, 0, 0),
+	}, surface{tex: solidTexture(3)})
+	n := filled(r, 3)
+	test.Assert(n > 0 && n <= 64*32, "expected floor below the horizon", t)
+	for x := 0; x < 64; x++ {
+		test.Assert(r.frame.Pix[x] != 3, "floor drawn above the horizon", t)
+	}
+}
+
+func TestMaskedTexture(t *testing.T) {
+	r := testRenderer(8, 8)
+	tex := &texture{width: 2, height: 1, pix: []uint8{5, transparentIndex}, masked: true}
+	r.blit(tex, 0, 0, 8, 8, 0)
+	test.Assert(filled(r, 5) == 32, "expected left half to be drawn", t)
+	test.Assert(filled(r, 0) == 32, "expected right half to be transparent", t)
+}
+
+func TestFuzzKeepsDepth(t *testing.T) {
+	r := testRenderer(64, 64)
+	r.drawPolygon(facingWall(r, 50), surface{tex: solidTexture(7), style: game.StyleFuzz})
+	test.Assert(filled(r, 7) == 0, "fuzz must not draw the sprite's colors", t)
+	r.drawPolygon(facingWall(r, 100), surface{tex: solidTexture(9)})
+	test.Assert(filled(r, 9) == 64*64, "fuzzy pixels must not hide what is behind them", t)
+}
+
+func TestTranslucentKeepsDepth(t *testing.T) {
+	r := testRenderer(64, 64)
+	r.tranMap = &graphics.TranMap{}
+	r.tranMap[7][9] = 11
+	r.drawPolygon(facingWall(r, 100), surface{tex: solidTexture(9)})
+	r.drawPolygon(facingWall(r, 50), surface{tex: solidTexture(7), style: game.StyleTranslucent})
+	test.Assert(filled(r, 11) == 64*64, "translucent pixels must be blended with the TRANMAP", t)
+	r.drawPolygon(facingWall(r, 75), surface{tex: solidTexture(5)})
+	test.Assert(filled(r, 5) == 64*64, "translucent pixels must not hide what is behind them", t)
+}
@@ -0,0 +1,571 @@
+// Package software renders the game on the CPU into a palette indexed framebuffer.
+// It needs no GPU and mirrors the drawing operations of the GL renderer.
+package software
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/tinogoehlert/goom/debug"
+	"github.com/tinogoehlert/goom/drivers/pkg"
+	"github.com/tinogoehlert/goom/game"
+	"github.com/tinogoehlert/goom/goom"
+	"github.com/tinogoehlert/goom/graphics"
+	"github.com/tinogoehlert/goom/level"
+	"github.com/tinogoehlert/goom/utils"
+)
+
+const (
+	// focalScale focal length relative to the screen height,
+	// 160 pixels on the 200 lines of the original screen
+	focalScale = 0.8
+	// fovMargin widens the culling frustum to keep geometry at the screen edges
+	fovMargin = 10
+	// hudHeight height of the HUD coordinate system used by the GL renderer
+	hudHeight = 640
+	// hudScale size of a HUD texel in HUD units
+	hudScale = 3
+	// skyColumns sky texture columns for a full turn
+	skyColumns = 1024
+	// skyTextureMid sky texture row at the horizon
+	skyTextureMid = 100
+	// skyFocal focal length the sky texture rows are measured in
+	skyFocal = 160
+)
+
+// Camera is the viewer of the software renderer.
+type Camera struct {
+	position  [2]float32
+	direction [3]float32
+	height    float32
+}
+
+// NewCamera creates a new camera
+func NewCamera() *Camera {
+	return &Camera{height: 45}
+}
+
+// SetCamera set the cam position
+func (cam *Camera) SetCamera(pos [2]float32, dir [3]float32, height float32) {
+	cam.position = pos
+	cam.direction = dir
+	cam.height = height
+}
+
+// Renderer renders frames on the CPU.
+type Renderer struct {
+	palette      graphics.Palette
+	palettes     *graphics.Palettes
+	paletteIndex int
+	gamma        int
+	// screen is the palette of the effect and gamma the frame is shown with
+	screen        graphics.Palette
+	colormap      *graphics.Colormap
+	textures      textureStore
+	camera        *Camera
+	currentLevel  *doomLevel
+	sky           *texture
+	frame         *image.Paletted
+	depth         []float32
+	width         int
+	height        int
+	fixedColormap int
+	animations    *graphics.Animations
+	sprites       graphics.SpriteStore
+	levelTime     int
+	// frac of the time between the last and the next game tic
+	frac float32
+	// masked walls and sprites drawn back to front after the opaque geometry
+	masked  pkg.MaskedPass
+	fuzz    graphics.Fuzz
+	tranMap *graphics.TranMap
+	// fixed resolution set by SetResolution, the window scales the frames
+	fixed bool
+
+	// view set up by updateView
+	focal   float32
+	centerX float32
+	centerY float32
+	forward utils.Vec2
+	right   utils.Vec2
+	yaw     float64
+}
+
+// NewRenderer creates a renderer with a framebuffer of width x height pixels.
+func NewRenderer(gd *goom.GameData, width, height int) (*Renderer, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("could not create renderer: invalid size %dx%d", width, height)
+	}
+	r := &Renderer{
+		palette:       gd.DefaultPalette(),
+		palettes:      gd.Palettes,
+		screen:        gd.DefaultPalette(),
+		colormap:      gd.Colormap,
+		tranMap:       gd.TranMap,
+		textures:      newTextureStore(),
+		camera:        NewCamera(),
+		fixedColormap: -1,
+		animations:    gd.Animations,
+		sprites:       gd.Sprites,
+	}
+	if r.tranMap == nil {
+		r.tranMap = graphics.NewTranMap(r.palette, graphics.TranslucencyAlpha)
+	}
+
+	for k, v := range gd.Textures {
+		r.textures.add(k, 0, newTexture(v, r.palette, true))
+	}
+
+	for k, v := range gd.Flats {
+		r.textures.add(k, 0, newTexture(v[0], r.palette, false))
+	}
+
+	for k, v := range gd.Fonts.GetAllGraphics() {
+		r.textures.add(k, 0, newTexture(v, r.palette, true))
+	}
+
+	for k, v := range gd.Graphics {
+		r.textures.add(k, 0, newTexture(v, r.palette, true))
+	}
+
+	// mirrored rotations share the texture of the rotation they mirror
+	sprites := make(map[graphics.Image]*texture)
+	for _, v := range gd.Sprites {
+		v.Frames(func(f *graphics.SpriteFrame) {
+			for i, img := range f.Angles() {
+				if img == nil {
+					continue
+				}
+				if _, ok := sprites[img]; !ok {
+					sprites[img] = newTexture(img, r.palette, true)
+				}
+				r.textures.add(f.Name(), i, sprites[img])
+			}
+		})
+	}
+	r.SetViewPort(width, height)
+	return r, nil
+}
+
+// LoadLevel builds the level
+func (r *Renderer) LoadLevel(m *level.Level, gd *goom.GameData) {
+	if r.currentLevel != nil {
+		r.currentLevel.release()
+	}
+	r.currentLevel = registerMap(m, gd)
+	r.sky = r.textures.Get(gd.Sky(m.Name), 0)
+}
+
+// Camera gets the camera
+func (r *Renderer) Camera() *Camera {
+	return r.camera
+}
+
+// SetCamera sets the camera position, direction and eye height.
+func (r *Renderer) SetCamera(pos [2]float32, dir [3]float32, height float32) {
+	r.camera.SetCamera(pos, dir, height)
+}
+
+// Frustum gets the horizontal view frustum of the camera on the map.
+// It is nil if the camera pitch is too steep to cull by the horizontal view.
+func (r *Renderer) Frustum() *level.Frustum {
+	if math.Abs(float64(r.camera.direction[2])) > 0.5 {
+		return nil
+	}
+	fov := float32(2*math.Atan(float64(r.centerX/r.focal))*180/math.Pi) + fovMargin
+	if fov >= 180 {
+		return nil
+	}
+	return level.NewFrustum(
+		utils.V2(r.camera.position[0], r.camera.position[1]),
+		utils.V2(-r.camera.direction[0], r.camera.direction[1]),
+		fov,
+	)
+}
+
+// SetViewPort resizes the framebuffer, unless its resolution is fixed.
+func (r *Renderer) SetViewPort(fbWidth, fbHeight int) {
+	if r.fixed {
+		r.updateView()
+		return
+	}
+	r.resize(fbWidth, fbHeight)
+}
+
+// SetResolution fixes the size of the framebuffer, e.g. to 320x200 for Doom's look.
+func (r *Renderer) SetResolution(width, height int) {
+	r.resize(width, height)
+	r.fixed = true
+}
+
+func (r *Renderer) resize(fbWidth, fbHeight int) {
+	if fbWidth <= 0 || fbHeight <= 0 {
+		return
+	}
+	if fbWidth != r.width || fbHeight != r.height {
+		r.width, r.height = fbWidth, fbHeight
+		r.frame = image.NewPaletted(image.Rect(0, 0, fbWidth, fbHeight), r.colorPalette())
+		r.depth = make([]float32, fbWidth*fbHeight)
+	}
+	r.updateView()
+}
+
+// SetLevelTime sets the game tic used to pick the frames of animated flats and textures.
+func (r *Renderer) SetLevelTime(tic int) {
+	r.levelTime = tic
+}
+
+// SetInterpolation sets the fraction of the tic things and moving sectors are interpolated with.
+func (r *Renderer) SetInterpolation(frac float32) {
+	r.frac = frac
+	if r.currentLevel != nil {
+		r.currentLevel.interpolate(frac)
+	}
+}
+
+// SetPalette selects the PLAYPAL palette of damage, pickup and power up effects.
+func (r *Renderer) SetPalette(index int) {
+	r.paletteIndex = index
+	r.updateScreenPalette()
+}
+
+// SetGamma sets the gamma correction level, see graphics.NumGammaLevels.
+func (r *Renderer) SetGamma(level int) {
+	r.gamma = level
+	r.updateScreenPalette()
+}
+
+func (r *Renderer) updateScreenPalette() {
+	p := r.palette
+	if r.palettes != nil {
+		p = r.palettes.Get(r.paletteIndex)
+	}
+	r.screen = p.WithGamma(r.gamma)
+	if r.frame != nil {
+		r.frame.Palette = r.colorPalette()
+	}
+}
+
+// SetPlayerPosition has no effect, the light fades by the distance to the camera.
+func (r *Renderer) SetPlayerPosition(pos mgl32.Vec3) {}
+
+// SetInvulnerability switches to the greyscale colormap of the invulnerability sphere.
+func (r *Renderer) SetInvulnerability(enabled bool) {
+	r.fixedColormap = -1
+	if enabled {
+		r.fixedColormap = graphics.InvulnerabilityColormap
+	}
+}
+
+// RenderNewFrame clears the framebuffer and sets up the view of the camera.
+func (r *Renderer) RenderNewFrame() {
+	for i := range r.frame.Pix {
+		r.frame.Pix[i] = 0
+		r.depth[i] = 0
+	}
+	r.masked.Reset()
+	r.updateView()
+}
+
+func (r *Renderer) updateView() {
+	var (
+		dir = utils.V2(-r.camera.direction[0], r.camera.direction[1])
+		l   = dir.Length()
+	)
+	if l == 0 {
+		dir, l = utils.V2(1, 0), 1
+	}
+	r.forward = dir.Normalize()
+	r.right = utils.V2(r.forward.Y(), -r.forward.X())
+	r.yaw = math.Atan2(float64(r.forward.Y()), float64(r.forward.X()))
+	r.focal = float32(r.height) * focalScale
+	r.centerX = float32(r.width) / 2
+	r.centerY = float32(r.height)/2 + r.camera.direction[2]/l*r.focal
+}
+
+// toCamera transforms a position on the map at height z into camera space.
+func (r *Renderer) toCamera(x, y, z, u, v float32) camVertex {
+	rel := utils.V2(x-r.camera.position[0], y-r.camera.position[1])
+	return camVertex{
+		x: rel.Dot(r.right),
+		y: z - r.camera.height,
+		z: rel.Dot(r.forward),
+		u: u,
+		v: v,
+	}
+}
+
+// DrawSubSector draws flats, walls and sky of the subsector idx.
+func (r *Renderer) DrawSubSector(idx int) {
+	if r.currentLevel == nil || idx < 0 || idx >= len(r.currentLevel.subSectors) {
+		return
+	}
+	r.currentLevel.update(idx)
+	s := r.currentLevel.subSectors[idx]
+
+	if s.sector != nil && len(s.polygon) >= 3 {
+		var (
+			floor = s.sector.LerpFloorHeight(r.frac)
+			ceil  = s.sector.LerpCeilHeight(r.frac)
+			light = s.sector.LightLevel()
+		)
+		if r.camera.height > floor {
+			r.drawFlat(s.polygon, floor, surface{tex: r.flat(s.sector.FloorTexture()), light: light})
+		}
+		if r.camera.height < ceil {
+			cs := surface{tex: r.flat(s.sector.CeilTexture()), light: light}
+			if s.sector.CeilTexture() == pkg.SkyFlatName {
+				cs = surface{sky: r.sky != nil}
+			}
+			r.drawFlat(s.polygon, ceil, cs)
+		}
+	}
+
+	for i := range s.walls {
+		w := &s.walls[i]
+		if w.Masked {
+			r.masked.Add(w.Distance(utils.V2(r.camera.position[0], r.camera.position[1])), func() { r.drawWall(w) })
+			continue
+		}
+		r.drawWall(w)
+	}
+}
+
+func (r *Renderer) drawWall(w *pkg.WallQuad) {
+	ws := surface{tex: r.wallTexture(w.TextureName()), light: w.LightLevel()}
+	if w.IsSky {
+		ws = surface{sky: r.sky != nil}
+	}
+	r.drawPolygon([]camVertex{
+		r.toCamera(w.Start.X(), w.Start.Y(), w.Bottom, w.U0, w.VBottom),
+		r.toCamera(w.Start.X(), w.Start.Y(), w.Top, w.U0, w.VTop),
+		r.toCamera(w.End.X(), w.End.Y(), w.Top, w.U1, w.VTop),
+		r.toCamera(w.End.X(), w.End.Y(), w.Bottom, w.U1, w.VBottom),
+	}, ws)
+}
+
+// flat gets the current animation frame of a flat.
+func (r *Renderer) flat(name string) *texture {
+	if tex := r.textures.Get(r.animations.Flat(name, r.levelTime), 0); tex != nil {
+		return tex
+	}
+	return r.textures.Get(name, 0)
+}
+
+// wallTexture gets the current animation frame of a wall texture.
+func (r *Renderer) wallTexture(name string) *texture {
+	if tex := r.textures.Get(r.animations.Texture(name, r.levelTime), 0); tex != nil {
+		return tex
+	}
+	return r.textures.Get(name, 0)
+}
+
+func (r *Renderer) drawFlat(poly []utils.Vec2, height float32, s surface) {
+	verts := make([]camVertex, len(poly))
+	for i, v := range poly {
+		verts[i] = r.toCamera(v.X(), v.Y(), height, v.X()/64, -v.Y()/64)
+	}
+	r.drawPolygon(verts, s)
+}
+
+// skyTexel gets the sky texture at a screen position,
+// columns follow the view angle and rows the height above the horizon.
+func (r *Renderer) skyTexel(x, y int) uint8 {
+	var (
+		angle = r.yaw - math.Atan(float64((float32(x)+0.5-r.centerX)/r.focal))
+		col   = float32(angle / (2 * math.Pi) * skyColumns)
+		row   = skyTextureMid + (float32(y)+0.5-r.centerY)*skyFocal/r.focal
+	)
+	index, _ := r.sky.sample(col/float32(r.sky.width), row/float32(r.sky.height))
+	return r.shade(index, 0)
+}
+
+// DrawThings draws the things as sprites facing the camera, together with the
+// masked walls of the drawn subsectors back to front.
+func (r *Renderer) DrawThings(things []game.Thingable) {
+	cam := utils.V2(r.camera.position[0], r.camera.position[1])
+	for _, t := range things {
+		if !t.IsShown() {
+			continue
+		}
+		sprite, ok := r.sprites[t.SpriteName()]
+		if !ok {
+			continue
+		}
+		frame := t.NextFrame()
+		view, ok := sprite.View(frame, t.Rotation(r.camera.position))
+		if !ok {
+			continue
+		}
+		tex := r.textures.Get(view.Name, view.Rotation)
+		if tex == nil {
+			continue
+		}
+		var (
+			pos           = t.LerpPosition(r.frac)
+			z             = t.LerpHeight(r.frac)
+			light float32 = 255
+			floor         = z
+		)
+		if sector := t.GetSector(); sector != nil {
+			light, floor = sector.LightLevel(), sector.LerpFloorHeight(r.frac)
+		}
+		sq, ok := pkg.PlaceSprite(z, floor, tex.width, tex.height, tex.left, tex.top)
+		if !ok {
+			continue
+		}
+		if view.Flip {
+			sq = sq.Flipped()
+		}
+		var (
+			c      = r.toCamera(pos[0], pos[1], 0, 0, 0)
+			x0     = c.x + sq.Left
+			x1     = c.x + sq.Right
+			y0     = c.y + sq.Bottom
+			y1     = c.y + sq.Top
+			u0, u1 = float32(0), float32(1)
+		)
+		if view.Flip {
+			u0, u1 = u1, u0
+		}
+		poly := []camVertex{
+			{x: x0, y: y0, z: c.z, u: u0, v: sq.VBottom},
+			{x: x0, y: y1, z: c.z, u: u0, v: 0},
+			{x: x1, y: y1, z: c.z, u: u1, v: 0},
+			{x: x1, y: y0, z: c.z, u: u1, v: sq.VBottom},
+		}
+		s := surface{tex: tex, light: light, style: t.RenderStyle(), bright: t.Bright(frame)}
+		r.masked.Add(cam.DistanceTo(utils.V2(pos[0], pos[1])), func() { r.drawPolygon(poly, s) })
+	}
+	r.masked.Flush()
+}
+
+// drawHudImage draws an image centered at pos in the HUD coordinates of the GL renderer,
+// x is measured from the right and y from the bottom of a 640 units high screen.
+func (r *Renderer) drawHudImage(sprite string, pos [2]float32, offsetX, offsetY, scaleFactor float32, cmap int) {
+	tex := r.textures.Get(sprite, 0)
+	if tex == nil {
+		return
+	}
+	var (
+		unit  = float32(r.height) / hudHeight
+		cx    = float32(r.width) - (pos[0]+offsetX)*unit
+		cy    = float32(r.height) - (pos[1]-float32(tex.top)+offsetY)*unit
+		halfW = float32(tex.width) * hudScale / 2 * scaleFactor * unit
+		halfH = float32(tex.height) * hudScale / 2 * scaleFactor * unit
+	)
+	r.blit(tex, cx-halfW, cy-halfH, cx+halfW, cy+halfH, cmap)
+}
+
+// DrawHUD draws the game hud
+func (r *Renderer) DrawHUD(player *game.Player, t float64) {
+	var (
+		aspect = float32(r.width) / float32(r.height)
+		w      = player.Weapon()
+		pos    = [2]float32{(hudHeight * aspect) / 2, 0}
+		cmap   = 0
+	)
+	if sector := player.GetSector(); sector != nil {
+		cmap = graphics.HUDLightIndex(sector.LightLevel())
+	}
+	frame, fire := w.NextFrames(t)
+	offset := w.LerpOffset(r.frac)
+
+	r.drawHudImage(
+		w.Sprite+string(frame),
+		pos,
+		+offset[0],
+		-offset[1]-30,
+		1,
+		cmap,
+	)
+
+	if fire != 255 {
+		r.drawHudImage(
+			w.FireSprite+string(fire),
+			pos,
+			w.FireOffset.X+offset[0],
+			w.FireOffset.Y+(-offset[1])-30,
+			1,
+			cmap,
+		)
+	}
+}
+
+// DrawHUdElement draws a single element to the HUD
+func (r *Renderer) DrawHUdElement(name string, xpos, ypos float32, scaleFactor float32) {
+	r.drawHudImage(name, [2]float32{xpos, ypos}, 0, 0, scaleFactor, 0)
+}
+
+// DrawGraphic draws a graphic at a position on the original 320x200 screen,
+// which is scaled to the framebuffer height and centered.
+func (r *Renderer) DrawGraphic(name string, x, y float32) {
+	tex := r.textures.Get(name, 0)
+	if tex == nil {
+		return
+	}
+	var (
+		unit = float32(r.height) / pkg.ScreenHeight
+		left = (float32(r.width) - pkg.ScreenWidth*unit) / 2
+		x0   = left + (x-float32(tex.left))*unit
+		y0   = (y - float32(tex.top)) * unit
+	)
+	r.blit(tex, x0, y0, x0+float32(tex.width)*unit, y0+float32(tex.height)*unit, 0)
+}
+
+// DrawOverlay draws the lines of the debug overlay on top of the frame.
+func (r *Renderer) DrawOverlay(o *debug.Overlay) {
+	o.View(r.width, r.height).DrawLines(o.Lines, func(x, y int, color uint8) {
+		r.frame.Pix[y*r.frame.Stride+x] = color
+	})
+}
+
+// FrameIndexed gets the framebuffer of palette indices.
+func (r *Renderer) FrameIndexed() *image.Paletted {
+	return r.frame
+}
+
+// Frame converts the framebuffer with the palette of the current effect and gamma.
+func (r *Renderer) Frame() *image.RGBA {
+	img := image.NewRGBA(r.frame.Rect)
+	for i, index := range r.frame.Pix {
+		c := r.screen.Colors[index]
+		copy(img.Pix[i*4:], []uint8{c.R, c.G, c.B, 255})
+	}
+	return img
+}
+
+// FinishFrame does nothing, the window presents the frame.
+func (r *Renderer) FinishFrame() {}
+
+// Screenshot gets the current frame.
+func (r *Renderer) Screenshot() (*image.RGBA, error) {
+	return r.Frame(), nil
+}
+
+// SavePNG writes the current frame to a PNG file.
+func (r *Renderer) SavePNG(file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("could not create %s: %s", file, err.Error())
+	}
+	defer f.Close()
+	if err := png.Encode(f, r.Frame()); err != nil {
+		return fmt.Errorf("could not encode %s: %s", file, err.Error())
+	}
+	return nil
+}
+
+func (r *Renderer) colorPalette() []color.Color {
+	p := make([]color.Color, len(r.screen.Colors))
+	for i, c := range r.screen.Colors {
+		p[i] = c
+	}
+	return p
+}
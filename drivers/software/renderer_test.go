@@ -0,0 +1,19 @@
+package software
+
+import (
+	"testing"
+
+	"github.com/tinogoehlert/goom/test"
+)
+
+func TestFixedResolution(t *testing.T) {
+	r := testRenderer(64, 48)
+	r.SetViewPort(80, 60)
+	test.Assert(r.width == 80 && r.height == 60, "expected the framebuffer resized to the window", t)
+
+	r.SetResolution(320, 200)
+	r.SetViewPort(800, 600)
+	img, err := r.Screenshot()
+	test.Check(err, t)
+	test.Assert(img.Rect.Dx() == 320 && img.Rect.Dy() == 200, "expected screenshots at the internal resolution", t)
+}
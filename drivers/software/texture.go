@@ -0,0 +1,85 @@
+package software
+
+import (
+	"math"
+
+	"github.com/tinogoehlert/goom/graphics"
+)
+
+// transparentIndex palette index of transparent texels, see graphics.Image.ToPaletted.
+const transparentIndex = 255
+
+// texture is an image of palette indices.
+type texture struct {
+	width  int
+	height int
+	left   int
+	top    int
+	pix    []uint8
+	// masked textures have holes with the index 255, flats are opaque
+	masked bool
+}
+
+func newTexture(img graphics.Image, palette graphics.Palette, masked bool) *texture {
+	if img == nil {
+		return nil
+	}
+	p := img.ToPaletted(palette.Colors)
+	return &texture{
+		width:  img.Width(),
+		height: img.Height(),
+		left:   img.Left(),
+		top:    img.Top(),
+		pix:    p.Pix,
+		masked: masked,
+	}
+}
+
+// sample gets the texel at the texture coordinates u, v, which repeat outside of 0 - 1.
+// ok is false for transparent texels.
+func (t *texture) sample(u, v float32) (index uint8, ok bool) {
+	var (
+		x = wrap(int(math.Floor(float64(u*float32(t.width)))), t.width)
+		y = wrap(int(math.Floor(float64(v*float32(t.height)))), t.height)
+	)
+	index = t.pix[y*t.width+x]
+	return index, !t.masked || index != transparentIndex
+}
+
+func wrap(i, n int) int {
+	i %= n
+	if i < 0 {
+		i += n
+	}
+	return i
+}
+
+// textureStore stores textures by name, sprite frames have one texture per angle.
+type textureStore map[string][]*texture
+
+func newTextureStore() textureStore {
+	return make(textureStore)
+}
+
+func (ts textureStore) add(name string, idx int, tex *texture) {
+	if _, ok := ts[name]; !ok {
+		ts[name] = make([]*texture, idx+1)
+	}
+	for len(ts[name]) <= idx {
+		ts[name] = append(ts[name], nil)
+	}
+	ts[name][idx] = tex
+}
+
+// Get gets the texture name with the index idx, falling back to index 0.
+// nil is returned for unknown names.
+func (ts textureStore) Get(name string, idx int) *texture {
+	tex, ok := ts[name]
+	if !ok || len(tex) == 0 {
+		return nil
+	}
+	if idx < 0 || idx >= len(tex) || tex[idx] == nil {
+		return tex[0]
+	}
+	return tex[idx]
+}
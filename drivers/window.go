@@ -1,5 +1,7 @@
 package drivers
 
+import "image"
+
 // Window interface for the DOOM engine
 type Window interface {
 	Open(title string, width, height int) error
@@ -7,3 +9,18 @@ type Window interface {
 	GetSize() (width, height int)
 	RunGame(input func(), update func(), render func(nextFrameDelta float64))
 }
+
+// FramePresenter is a window that can show frames rendered on the CPU.
+type FramePresenter interface {
+	// SetSoftware opens the window without GL context, it must be called before Open.
+	SetSoftware(enabled bool)
+	// PresentFrame shows the frame scaled to the window size.
+	PresentFrame(frame *image.RGBA) error
+}
+
+// OffscreenWindow is a window that can create its GL context without being shown,
+// for headless rendering into offscreen framebuffers.
+type OffscreenWindow interface {
+	// SetOffscreen hides the window, it must be called before Open.
+	SetOffscreen(enabled bool)
+}
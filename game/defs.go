@@ -12,6 +12,9 @@ type ThingDef struct {
 	ID        int    `yaml:"id"`
 	Sprite    string `yaml:"sprite"`
 	Animation string `yaml:"anim"`
+	Style     string `yaml:"style"`
+	// Bright frames are drawn fullbright, ignoring the sector light
+	Bright string `yaml:"bright"`
 }
 
 // MonsterDef monster definitions
@@ -21,6 +24,9 @@ type MonsterDef struct {
 	Sprite     string            `yaml:"sprite"`
 	Sounds     map[string]string `yaml:"sounds"`
 	Animations map[string]string `yaml:"anim"`
+	Style      string            `yaml:"style"`
+	// Bright frames are drawn fullbright, ignoring the sector light
+	Bright string `yaml:"bright"`
 }
 
 // ItemDef monster definitions
@@ -30,6 +36,9 @@ type ItemDef struct {
 	Animation string `yaml:"anim"`
 	Category  string `yaml:"category"`
 	Reference string `yaml:"ref"`
+	Style     string `yaml:"style"`
+	// Bright frames are drawn fullbright, ignoring the sector light
+	Bright string `yaml:"bright"`
 }
 
 // DefStore holds DOOM definitions e.g. monsters, weapons and obstacles
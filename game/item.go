@@ -16,6 +16,8 @@ func ItemFromDef(x, y, angle float32, def *ItemDef) *Item {
 	item.id = def.ID
 	item.category = def.Category
 	item.ref = def.Reference
+	item.style = renderStyle(def.Style)
+	item.brightFrames = def.Bright
 	return item
 }
 
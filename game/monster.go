@@ -40,6 +40,8 @@ func MonsterFromDef(x, y, sx, sy, angle float32, def *MonsterDef) *Monster {
 	m.health = def.Health
 	m.sizeX = sx
 	m.sizeY = sy
+	m.style = renderStyle(def.Style)
+	m.brightFrames = def.Bright
 	for k, v := range def.Sounds {
 		switch k {
 		case "hit":
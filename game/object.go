@@ -3,6 +3,7 @@ package game
 import (
 	"log"
 	"math"
+	"strings"
 	"time"
 
 	"github.com/go-gl/mathgl/mgl32"
@@ -10,6 +11,30 @@ import (
 	"github.com/tinogoehlert/goom/level"
 )
 
+// RenderStyle is how the sprite of a thing is blended with what is behind it.
+type RenderStyle int
+
+const (
+	// StyleNormal draws the opaque pixels of the sprite.
+	StyleNormal RenderStyle = iota
+	// StyleTranslucent blends the sprite with what is behind it.
+	StyleTranslucent
+	// StyleFuzz darkens what is behind the shape of the sprite,
+	// like the partial invisibility of the spectre.
+	StyleFuzz
+)
+
+var renderStyles = map[string]RenderStyle{
+	"normal":      StyleNormal,
+	"translucent": StyleTranslucent,
+	"fuzz":        StyleFuzz,
+}
+
+// renderStyle gets the style of a definition, unknown styles are drawn normal.
+func renderStyle(name string) RenderStyle {
+	return renderStyles[name]
+}
+
 // Thingable is a type that behaves like a thing
 type Thingable interface {
 	GetID() int
@@ -17,10 +42,14 @@ type Thingable interface {
 	Position() [2]float32
 	Direction() [3]float32
 	Height() float32
+	LerpPosition(frac float32) [2]float32
+	LerpHeight(frac float32) float32
 	NextFrame() byte
 	SetHeight(height float32)
-	CalcAngle(origin mgl32.Vec2) (int, int)
+	Rotation(origin mgl32.Vec2) int
 	SpriteName() string
+	RenderStyle() RenderStyle
+	Bright(frame byte) bool
 	IsShown() bool
 	GetSector() *level.Sector
 	SetSector(sector *level.Sector)
@@ -42,6 +71,12 @@ type DoomThing struct {
 	hasAngles        bool
 	freeze           bool
 	currentSector    *level.Sector
+	style            RenderStyle
+	brightFrames     string
+	// state of the last tic to interpolate with
+	prevPosition [2]float32
+	prevHeight   float32
+	saved        bool
 }
 
 // ThingFromDef creates thing from definition
@@ -50,6 +85,8 @@ func ThingFromDef(x, y, angle float32, def *ThingDef) *DoomThing {
 	m.animations["idle"] = []byte(def.Animation)
 	m.currentAnimation = m.animations["idle"]
 	m.id = def.ID
+	m.style = renderStyle(def.Style)
+	m.brightFrames = def.Bright
 	return m
 }
 
@@ -67,9 +104,8 @@ func NewDoomThing(x, y, angle float32, sprite string, hasAngles bool) *DoomThing
 func appendDoomThing(dst []Thingable, src Thingable, m *level.Level) []Thingable {
 	var ssect, err = m.FindPositionInBsp(level.GLNodesName, src.Position()[0], src.Position()[1])
 	if err != nil {
-		log.Printf("could not find GLnode for pos %v\n", src.Position())
-	} else {
-		var sector = m.SectorFromSSect(ssect)
+		log.Printf("could not find subsector for pos %v: %s\n", src.Position(), err.Error())
+	} else if sector := m.SectorFromSSect(ssect); sector != nil {
 		src.SetSector(sector)
 		src.SetHeight(sector.FloorHeight())
 	}
@@ -105,6 +141,30 @@ func (dt *DoomThing) Direction() [3]float32 {
 	return dt.direction
 }
 
+// LerpPosition interpolates the position between the last and the current tic.
+func (dt *DoomThing) LerpPosition(frac float32) [2]float32 {
+	if !dt.saved {
+		return dt.position
+	}
+	return [2]float32{
+		dt.prevPosition[0] + (dt.position[0]-dt.prevPosition[0])*frac,
+		dt.prevPosition[1] + (dt.position[1]-dt.prevPosition[1])*frac,
+	}
+}
+
+// LerpHeight interpolates the height between the last and the current tic.
+func (dt *DoomThing) LerpHeight(frac float32) float32 {
+	if !dt.saved {
+		return dt.height
+	}
+	return dt.prevHeight + (dt.height-dt.prevHeight)*frac
+}
+
+// saveState keeps the state of the tic to interpolate with.
+func (dt *DoomThing) saveState() {
+	dt.prevPosition, dt.prevHeight, dt.saved = dt.position, dt.height, true
+}
+
 // IsShown determines if consumable was consumed
 func (dt *DoomThing) IsShown() bool {
 	return true
@@ -130,6 +190,16 @@ func (dt *DoomThing) SpriteName() string {
 	return dt.sprite
 }
 
+// RenderStyle gets how the thing's sprite is blended.
+func (dt *DoomThing) RenderStyle() RenderStyle {
+	return dt.style
+}
+
+// Bright checks if a frame is drawn fullbright.
+func (dt *DoomThing) Bright(frame byte) bool {
+	return strings.IndexByte(dt.brightFrames, frame) >= 0
+}
+
 // NextFrame gets the next frame of the current animation
 func (dt *DoomThing) NextFrame() byte {
 	if dt.freeze {
@@ -146,35 +216,17 @@ func (dt *DoomThing) NextFrame() byte {
 	return dt.currentAnimation[dt.currentFrame]
 }
 
-// CalcAngle has really crazy math inside.
-func (dt *DoomThing) CalcAngle(origin mgl32.Vec2) (int, int) {
+// Rotation gets the sprite rotation 1-8 the thing is seen with from origin,
+// 1 facing the viewer and counting counter-clockwise around the thing, 0 without rotations.
+func (dt *DoomThing) Rotation(origin mgl32.Vec2) int {
 	if !dt.hasAngles {
-		return 0, 0
+		return 0
 	}
 	dist := origin.Sub(dt.position)
 	angle := mgl32.RadToDeg(float32(math.Atan2(float64(dist.Y()), float64(dist.X())))) - dt.hAngle
-
-	if angle < 0.0 {
+	angle = float32(math.Mod(float64(angle)+22.5, 360))
+	if angle < 0 {
 		angle += 360
 	}
-	switch {
-	case (angle >= 337.5 || angle < 22.5):
-		return 1, 0
-	case (angle < 67.5):
-		return 2, 0
-	case (angle < 112.5):
-		return 3, 0
-	case (angle < 157.5):
-		return 4, 0
-	case (angle < 202.5):
-		return 5, 0
-	case (angle < 247.5):
-		return 3, 1
-	case (angle < 292.5):
-		return 4, 1
-	case (angle < 337.5):
-		return 2, 1
-	default:
-		return 1, 0
-	}
+	return int(angle/45)%8 + 1
 }
@@ -3,6 +3,7 @@ package game
 import (
 	"time"
 
+	"github.com/tinogoehlert/goom/graphics"
 	"github.com/tinogoehlert/goom/utils"
 )
 
@@ -19,6 +20,18 @@ type Player struct {
 	maxSpeed     float32
 	currSpeed    float32
 	targetHeight float32
+	walking      bool
+	health       int
+	armor        int
+	ammo         [NumAmmo]int
+	maxAmmo      [NumAmmo]int
+	keys         [NumKeys]bool
+	god          bool
+	// palette effects, counted in game tics
+	damageCount   int
+	bonusCount    int
+	berserk       int
+	radiationSuit int
 }
 
 // NewPlayer creates a new player with the given values
@@ -34,7 +47,10 @@ func NewPlayer(x, y, hAngle, vAngle float32, w *World) *Player {
 		world:     w,
 		weaponBag: make(map[string]*Weapon),
 		maxSpeed:  0.5,
+		health:    100,
+		maxAmmo:   maxAmmo,
 	}
+	p.ammo[AmmoClip] = 50
 
 	p.SetDirectionAngles(hAngle, vAngle)
 
@@ -43,14 +59,14 @@ func NewPlayer(x, y, hAngle, vAngle float32, w *World) *Player {
 
 // Forward sets the player to a forward moving state using the given speed.
 func (p *Player) Forward(speed float32) {
-	p.weapon.bobbing()
+	p.walking = true
 	p.currSpeed = utils.Clamp(p.currSpeed+speed, -p.maxSpeed, p.maxSpeed)
 	p.velocityX += p.currSpeed
 }
 
 // Strafe sets the player to a sideways moving state using the given speed.
 func (p *Player) Strafe(speed float32) {
-	p.weapon.bobbing()
+	p.walking = true
 	p.currSpeed = utils.Clamp(p.currSpeed+speed, -p.maxSpeed, p.maxSpeed)
 	p.velocityY += p.currSpeed
 }
@@ -75,8 +91,25 @@ func (p *Player) Height() float32 {
 	return p.DoomThing.height + 41
 }
 
+// LerpHeight interpolates the player's height between the last and the current tic.
+func (p *Player) LerpHeight(frac float32) float32 {
+	return p.DoomThing.LerpHeight(frac) + 41
+}
+
+// saveState keeps the position and the weapon bob of the tic to interpolate with.
+func (p *Player) saveState() {
+	p.DoomThing.saveState()
+	if p.weapon != nil {
+		p.weapon.prevOffset = p.weapon.offset
+	}
+}
+
 // Update updates all velocities and to deacclerate all types of movement.
 func (p *Player) Update() {
+	if p.walking && p.weapon != nil {
+		p.weapon.bobbing()
+	}
+	p.walking = false
 	p.velocityX *= 0.90
 	p.Movable.Walk(p.velocityX)
 	p.velocityY *= 0.90
@@ -90,8 +123,16 @@ func (p *Player) Update() {
 // AddWeapon add a new weapon into player's bag or adds ammo
 // if he weapon is already in the bag
 func (p *Player) AddWeapon(weapon *Weapon) {
-	if w, ok := p.weaponBag[weapon.Name]; ok {
-		w.ammo += 20
+	if t := weapon.AmmoType(); t != AmmoNone {
+		p.GiveAmmo(t, 2*clipAmmo[t])
+	}
+	p.bonusCount += graphics.BonusAdd
+	p.addWeapon(weapon)
+}
+
+// addWeapon adds a weapon without ammunition, like the pistol a player starts with.
+func (p *Player) addWeapon(weapon *Weapon) {
+	if _, ok := p.weaponBag[weapon.Name]; ok {
 		return
 	}
 	p.weaponBag[weapon.Name] = weapon
@@ -100,7 +141,14 @@ func (p *Player) AddWeapon(weapon *Weapon) {
 
 // FireWeapon --> BOOOM!
 func (p *Player) FireWeapon() {
+	t := p.weapon.AmmoType()
+	if t != AmmoNone && p.ammo[t] < p.weapon.ammoPerShot() {
+		return
+	}
 	if p.weapon.Fire() {
+		if t != AmmoNone {
+			p.ammo[t] -= p.weapon.ammoPerShot()
+		}
 		// TODO: play correct sounds for other weapons
 		// TODO: reuse playback device instead of naive playback
 		p.world.spawnShot(p)
@@ -128,3 +176,125 @@ func (p *Player) SwitchWeapon(name string) {
 func (p *Player) Weapon() *Weapon {
 	return p.weapon
 }
+
+// GiveAmmo adds ammunition up to the maximum the player can carry.
+func (p *Player) GiveAmmo(t AmmoType, amount int) {
+	if t < 0 || t >= NumAmmo {
+		return
+	}
+	p.ammo[t] += amount
+	if p.ammo[t] > p.maxAmmo[t] {
+		p.ammo[t] = p.maxAmmo[t]
+	}
+}
+
+// GiveArmor sets the armor points if they are higher than the current ones.
+func (p *Player) GiveArmor(points int) {
+	if points > p.armor {
+		p.armor = points
+	}
+}
+
+// GiveKey adds a key card or skull key.
+func (p *Player) GiveKey(k Key) {
+	if k >= 0 && k < NumKeys {
+		p.keys[k] = true
+	}
+}
+
+// SetGod enables or disables god mode.
+func (p *Player) SetGod(god bool) {
+	p.god = god
+}
+
+// Damage hurts the player, armor absorbs a third of the damage
+// as long as there are armor points left.
+func (p *Player) Damage(amount int) {
+	if p.god || p.health <= 0 || amount <= 0 {
+		return
+	}
+	saved := amount / 3
+	if saved > p.armor {
+		saved = p.armor
+	}
+	p.armor -= saved
+	p.health -= amount - saved
+	p.damageCount += amount - saved
+	if p.damageCount > graphics.MaxDamageCount {
+		p.damageCount = graphics.MaxDamageCount
+	}
+	if p.health < 0 {
+		p.health = 0
+	}
+}
+
+// GiveBerserk gives a berserk pack, which heals up to 100% and tints the screen red.
+func (p *Player) GiveBerserk() {
+	if p.health < 100 {
+		p.health = 100
+	}
+	p.berserk = 1
+	p.bonusCount += graphics.BonusAdd
+}
+
+// GiveRadiationSuit gives a radiation shielding suit.
+func (p *Player) GiveRadiationSuit() {
+	p.radiationSuit = graphics.RadiationSuitTics
+	p.bonusCount += graphics.BonusAdd
+}
+
+// PaletteEffects gets the values selecting the palette the player sees.
+func (p *Player) PaletteEffects() graphics.PaletteEffects {
+	return graphics.PaletteEffects{
+		DamageCount:   p.damageCount,
+		BonusCount:    p.bonusCount,
+		Berserk:       p.berserk,
+		RadiationSuit: p.radiationSuit,
+	}
+}
+
+// tick counts down the palette effects once per game tic.
+func (p *Player) tick() {
+	if p.damageCount > 0 {
+		p.damageCount--
+	}
+	if p.bonusCount > 0 {
+		p.bonusCount--
+	}
+	if p.berserk > 0 {
+		p.berserk++
+	}
+	if p.radiationSuit > 0 {
+		p.radiationSuit--
+	}
+}
+
+// Health gets the player's health.
+func (p *Player) Health() int {
+	return p.health
+}
+
+// Status gets the values shown in the status bar.
+func (p *Player) Status() Status {
+	s := Status{
+		Health:    p.health,
+		Armor:     p.armor,
+		Ammo:      -1,
+		AmmoTable: p.ammo,
+		MaxAmmo:   p.maxAmmo,
+		Keys:      p.keys,
+		Weapons:   len(p.weaponBag),
+		God:       p.god,
+	}
+	if p.weapon != nil {
+		if t := p.weapon.AmmoType(); t != AmmoNone {
+			s.Ammo = p.ammo[t]
+		}
+	}
+	for _, w := range p.weaponBag {
+		if w.Slot >= 2 && w.Slot < 2+NumArms {
+			s.Arms[w.Slot-2] = true
+		}
+	}
+	return s
+}
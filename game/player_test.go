@@ -0,0 +1,45 @@
+package game
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tinogoehlert/goom/graphics"
+	"github.com/tinogoehlert/goom/test"
+)
+
+func TestDamagePalette(t *testing.T) {
+	for tics, want := range map[int]int{0: 6, 16: 4, 39: 2, 40: 0} {
+		p := NewPlayer(0, 0, 0, 0, nil)
+		p.Damage(40)
+		for i := 0; i < tics; i++ {
+			p.tick()
+		}
+		got := p.PaletteEffects().PaletteIndex()
+		test.Assert(got == want, fmt.Sprintf("palette after %d tics is %d, want %d", tics, got, want), t)
+	}
+}
+
+func TestPowerupPalette(t *testing.T) {
+	p := NewPlayer(0, 0, 0, 0, nil)
+	palette := func(tics int, want int, msg string) {
+		for i := 0; i < tics; i++ {
+			p.tick()
+		}
+		got := p.PaletteEffects().PaletteIndex()
+		test.Assert(got == want, fmt.Sprintf("%s: palette is %d, want %d", msg, got, want), t)
+	}
+
+	p.GiveBerserk()
+	palette(0, 3, "berserk over bonus")
+	p.Damage(40)
+	palette(0, 6, "damage over berserk")
+	palette(40, 3, "berserk after the damage")
+	palette(12<<6, 0, "berserk faded")
+
+	p.GiveRadiationSuit()
+	palette(0, 10, "bonus over suit")
+	palette(6, 13, "suit")
+	palette(graphics.RadiationSuitTics-6-4*32, 0, "suit running out")
+	palette(8, 13, "suit blinking")
+}
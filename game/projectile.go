@@ -5,6 +5,7 @@ type Projectile struct {
 	*Movable
 	damage   int
 	maxRange int
+	origin   [2]float32
 }
 
 // NewProjectile returns a new projectile.
@@ -18,5 +19,16 @@ func NewProjectile(pos [2]float32, dir [3]float32, damage, maxRange int) *Projec
 		},
 		maxRange: maxRange,
 		damage:   damage,
+		origin:   pos,
 	}
 }
+
+// Origin gets the position the projectile was fired from.
+func (p *Projectile) Origin() [2]float32 {
+	return p.origin
+}
+
+// MaxRange gets the distance the projectile flies.
+func (p *Projectile) MaxRange() int {
+	return p.maxRange
+}
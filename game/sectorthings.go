@@ -0,0 +1,63 @@
+package game
+
+// noSector links the things outside of any sector, they are always drawn.
+const noSector = -1
+
+// sectorThings links the things into the sectors they are in, like the thing
+// lists of vanilla's sectors. Renderers only look at the things of visible sectors.
+type sectorThings struct {
+	linked   map[int][]Thingable
+	sectorOf map[Thingable]int
+}
+
+func newSectorThings() sectorThings {
+	return sectorThings{
+		linked:   make(map[int][]Thingable),
+		sectorOf: make(map[Thingable]int),
+	}
+}
+
+// sectorIndex gets the sector the thing belongs to.
+func sectorIndex(t Thingable) int {
+	if sector := t.GetSector(); sector != nil {
+		return sector.Index()
+	}
+	return noSector
+}
+
+// link adds the thing to the list of its sector.
+func (st *sectorThings) link(t Thingable) {
+	idx := sectorIndex(t)
+	st.linked[idx] = append(st.linked[idx], t)
+	st.sectorOf[t] = idx
+}
+
+// unlink removes the thing from the list of its sector.
+func (st *sectorThings) unlink(t Thingable) {
+	idx, ok := st.sectorOf[t]
+	if !ok {
+		return
+	}
+	things := st.linked[idx]
+	for i := range things {
+		if things[i] == t {
+			st.linked[idx] = append(things[:i], things[i+1:]...)
+			break
+		}
+	}
+	delete(st.sectorOf, t)
+}
+
+// relink moves the thing to the list of the sector it is in now.
+func (st *sectorThings) relink(t Thingable) {
+	if idx, ok := st.sectorOf[t]; ok && idx == sectorIndex(t) {
+		return
+	}
+	st.unlink(t)
+	st.link(t)
+}
+
+// in gets the things linked into the sector idx.
+func (st *sectorThings) in(idx int) []Thingable {
+	return st.linked[idx]
+}
@@ -0,0 +1,127 @@
+package game
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/tinogoehlert/goom/level"
+	"github.com/tinogoehlert/goom/test"
+	"github.com/tinogoehlert/goom/wad"
+)
+
+func i16s(values ...int16) []byte {
+	buff := make([]byte, len(values)*2)
+	for i, v := range values {
+		binary.LittleEndian.PutUint16(buff[i*2:], uint16(v))
+	}
+	return buff
+}
+
+// twoSectorLevel is a 64x64 square split at x=32 into the subsectors 0 and 1,
+// the right half is in sector 0 and the left half in sector 1.
+func twoSectorLevel(t *testing.T) *level.Level {
+	sidedefs := make([]byte, 2*30)
+	copy(sidedefs[20:], "STARTAN3")
+	copy(sidedefs[28:], i16s(0))
+	copy(sidedefs[30+20:], "STARTAN3")
+	copy(sidedefs[30+28:], i16s(1))
+	sectors := make([]byte, 2*26)
+	copy(sectors, i16s(0, 128))
+	copy(sectors[26:], i16s(0, 128))
+
+	lump := func(name string, data []byte) wad.Lump {
+		return wad.Lump{Name: name, Size: len(data), Data: data}
+	}
+	l, err := level.NewLevel([]wad.Lump{
+		lump(level.ThingsName, nil),
+		lump(level.LinedefsName, i16s(
+			0, 1, 1, 0, 0, 1, -1,
+			1, 2, 1, 0, 0, 0, -1,
+			2, 3, 1, 0, 0, 0, -1,
+			3, 0, 1, 0, 0, 0, -1,
+		)),
+		lump(level.SidedefsName, sidedefs),
+		lump(level.VertsName, i16s(0, 0, 0, 64, 64, 64, 64, 0, 32, 64, 32, 0)),
+		lump(level.SegsName, i16s(
+			4, 2, 0, 1, 0, 32,
+			2, 3, 0, 2, 0, 0,
+			3, 5, 0, 3, 0, 0,
+			0, 1, 0, 0, 0, 0,
+			1, 4, 0, 1, 0, 0,
+			5, 0, 0, 3, 0, 32,
+		)),
+		lump(level.SSectsName, i16s(3, 0, 3, 3)),
+		lump(level.NodesName, append(
+			i16s(32, 0, 0, 64, 64, 0, 32, 64, 64, 0, 0, 32),
+			i16s(-32768, 1|-32768)...,
+		)),
+		lump(level.SectorsName, sectors),
+	})
+	test.Check(err, t)
+	return l
+}
+
+// worldWith links the things into the sectors of the level.
+func worldWith(l *level.Level, things ...Thingable) *World {
+	w := &World{levelRef: l, inSectors: newSectorThings()}
+	for _, t := range things {
+		w.things = appendDoomThing(w.things, t, l)
+		w.inSectors.link(t)
+	}
+	return w
+}
+
+func contains(things []Thingable, t Thingable) bool {
+	for _, other := range things {
+		if other == t {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRelinkMovesThing(t *testing.T) {
+	var (
+		l = twoSectorLevel(t)
+		m = NewMonster(48, 32, 0, "POSS")
+		w = worldWith(l, m)
+	)
+	test.Assert(m.GetSector().Index() == 0, "expected the monster in the right half", t)
+	test.Assert(contains(w.inSectors.in(0), m), "monster not linked into its sector", t)
+
+	m.position[0] = 16
+	w.relink(m)
+	test.Assert(m.GetSector().Index() == 1, "expected the monster in the left half", t)
+	test.Assert(contains(w.inSectors.in(1), m), "monster not linked into its new sector", t)
+	test.Assert(!contains(w.inSectors.in(0), m), "monster still linked into its old sector", t)
+}
+
+func TestVisibleThingsCullsHiddenSectors(t *testing.T) {
+	var (
+		l       = twoSectorLevel(t)
+		m       = NewMonster(48, 32, 0, "POSS")
+		w       = worldWith(l, m)
+		rightVS = &level.VisibleSet{Order: []int{0}}
+	)
+	things, culled := w.VisibleThings(rightVS)
+	test.Assert(contains(things, m) && culled == 0, "monster in a visible sector must be drawn", t)
+
+	m.position[0] = 16
+	w.relink(m)
+	things, culled = w.VisibleThings(rightVS)
+	test.Assert(!contains(things, m) && culled == 1, "monster crossed into a hidden sector must be culled", t)
+}
+
+func TestVisibleThingsKeepsThingsWithoutSector(t *testing.T) {
+	var (
+		l       = twoSectorLevel(t)
+		outside = NewDoomThing(16, 32, 0, "BAR1", false)
+		w       = &World{levelRef: l, inSectors: newSectorThings()}
+	)
+	w.things = append(w.things, outside)
+	w.inSectors.link(outside)
+	test.Assert(contains(w.inSectors.in(noSector), outside), "expected the thing without a sector in noSector", t)
+
+	things, culled := w.VisibleThings(&level.VisibleSet{Order: []int{0}})
+	test.Assert(contains(things, outside) && culled == 0, "things without a sector must always be drawn", t)
+}
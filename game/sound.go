@@ -0,0 +1,23 @@
+package game
+
+import "github.com/tinogoehlert/goom/audio/music"
+
+// Audio plays the sounds of the world, e.g. drivers.Audio
+type Audio interface {
+	Play(name string) error
+	PlayAtPosition(name string, distance float32, angle int16) error
+}
+
+// Music plays the music of the levels, e.g. drivers.Music
+type Music interface {
+	PlayMusic(m *music.Track) error
+}
+
+// silence plays nothing until the world gets audio drivers.
+type silence struct{}
+
+func (silence) Play(name string) error { return nil }
+
+func (silence) PlayAtPosition(name string, distance float32, angle int16) error { return nil }
+
+func (silence) PlayMusic(m *music.Track) error { return nil }
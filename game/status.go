@@ -0,0 +1,61 @@
+package game
+
+// AmmoType kind of ammunition a weapon uses
+type AmmoType int
+
+// ammunition types in the order of the vanilla status bar
+const (
+	AmmoNone AmmoType = iota - 1
+	AmmoClip
+	AmmoShell
+	AmmoCell
+	AmmoMissile
+	NumAmmo
+)
+
+// ammoNames names used for the ammunition in defs.yaml
+var ammoNames = map[string]AmmoType{
+	"clip":    AmmoClip,
+	"shell":   AmmoShell,
+	"cell":    AmmoCell,
+	"missile": AmmoMissile,
+}
+
+// ammo given by a clip, a weapon pickup gives two of them
+var (
+	clipAmmo = [NumAmmo]int{10, 4, 20, 1}
+	maxAmmo  = [NumAmmo]int{200, 50, 300, 50}
+)
+
+// Key key cards and skull keys
+type Key int
+
+// keys in the order of the vanilla status bar graphics STKEYS0 - STKEYS5
+const (
+	KeyBlueCard Key = iota
+	KeyYellowCard
+	KeyRedCard
+	KeyBlueSkull
+	KeyYellowSkull
+	KeyRedSkull
+	NumKeys
+)
+
+// NumArms weapon slots 2 - 7 shown in the arms panel
+const NumArms = 6
+
+// Status values of a player shown in the status bar.
+type Status struct {
+	Health int
+	Armor  int
+	// Ammo ammunition of the current weapon, -1 if it uses none
+	Ammo      int
+	AmmoTable [NumAmmo]int
+	MaxAmmo   [NumAmmo]int
+	// Arms owned weapon slots 2 - 7
+	Arms [NumArms]bool
+	Keys [NumKeys]bool
+	// Weapons number of weapons owned
+	Weapons int
+	God     bool
+}
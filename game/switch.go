@@ -0,0 +1,67 @@
+package game
+
+import (
+	"github.com/tinogoehlert/goom/graphics"
+	"github.com/tinogoehlert/goom/level"
+)
+
+// buttonTime tics until a repeatable switch reverts
+const buttonTime = graphics.TicRate
+
+// button is a pressed repeatable switch waiting to revert.
+type button struct {
+	side     *level.SideDef
+	part     level.SidePart
+	texture  string
+	revertAt int
+}
+
+// ChangeSwitchTexture flips the switch texture on the front side of line.
+// Repeatable switches revert after a second. false is returned if the line has no switch texture.
+func (w *World) ChangeSwitchTexture(line *level.LineDef, repeatable bool) bool {
+	if w.levelRef == nil || line.Right < 0 || int(line.Right) >= len(w.levelRef.SideDefs) {
+		return false
+	}
+	side := &w.levelRef.SideDefs[line.Right]
+	for _, b := range w.buttons {
+		if b.side == side {
+			return true
+		}
+	}
+	for _, part := range []level.SidePart{level.SideUpper, level.SideMiddle, level.SideLower} {
+		name := side.Texture(part)
+		other, ok := w.gameData.Switches.Toggle(name)
+		if !ok {
+			continue
+		}
+		side.SetTexture(part, other)
+		w.Audio.Play("DSSWTCHN")
+		if repeatable {
+			w.buttons = append(w.buttons, button{
+				side:     side,
+				part:     part,
+				texture:  name,
+				revertAt: w.LevelTime() + buttonTime,
+			})
+		}
+		return true
+	}
+	return false
+}
+
+// updateButtons reverts the switches whose time is up.
+func (w *World) updateButtons() {
+	var (
+		now    = w.LevelTime()
+		active = w.buttons[:0]
+	)
+	for _, b := range w.buttons {
+		if now < b.revertAt {
+			active = append(active, b)
+			continue
+		}
+		b.side.SetTexture(b.part, b.texture)
+		w.Audio.Play("DSSWTCHN")
+	}
+	w.buttons = active
+}
@@ -0,0 +1,39 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/tinogoehlert/goom/goom"
+	"github.com/tinogoehlert/goom/graphics"
+	"github.com/tinogoehlert/goom/level"
+	"github.com/tinogoehlert/goom/test"
+)
+
+func TestRepeatableSwitchReverts(t *testing.T) {
+	switches := graphics.NewSwitches()
+	switches.InitPairs(graphics.TextureStore{"SW1BRCOM": &graphics.Texture{}, "SW2BRCOM": &graphics.Texture{}})
+	var (
+		w = &World{
+			Audio:    silence{},
+			gameData: &goom.GameData{Switches: switches},
+			levelRef: &level.Level{SideDefs: make([]level.SideDef, 1)},
+		}
+		line = &level.LineDef{Right: 0, Left: -1, SpecialType: 62}
+	)
+	w.levelRef.SideDefs[0].SetTexture(level.SideMiddle, "SW1BRCOM")
+	middle := func() string { return w.levelRef.SideDefs[0].Texture(level.SideMiddle) }
+
+	test.Assert(w.ChangeSwitchTexture(line, true), "switch not flipped", t)
+	test.Assert(middle() == "SW2BRCOM", "switch shows "+middle(), t)
+
+	for w.LevelTime() < buttonTime-1 {
+		w.updates++
+		w.updateButtons()
+	}
+	test.Assert(middle() == "SW2BRCOM", "switch reverted too early", t)
+
+	w.updates += updatesPerSecond
+	w.updateButtons()
+	test.Assert(middle() == "SW1BRCOM", "switch not reverted: "+middle(), t)
+	test.Assert(len(w.buttons) == 0, "button still waiting", t)
+}
@@ -18,15 +18,19 @@ type Weapon struct {
 	Damage     int    `yaml:"damage"`
 	Range      int    `yaml:"range"`
 	Sound      string `yaml:"sound"`
-	FireOffset struct {
+	Slot       int    `yaml:"slot"`
+	Ammo       string `yaml:"ammo"`
+	// AmmoPerShot ammunition used by one shot, defaults to 1
+	AmmoPerShot int `yaml:"ammoPerShot"`
+	FireOffset  struct {
 		X float32 `yaml:"x"`
 		Y float32 `yaml:"y"`
 	} `yaml:"fire_offset"`
-	ammo         int
 	Animations   map[string]string `yaml:"anim"`
 	state        int
 	lastTick     time.Time
 	offset       [2]float32
+	prevOffset   [2]float32
 	pulledDown   func()
 	currentFrame int
 	bobPhase     float64
@@ -37,6 +41,33 @@ func (w *Weapon) Offset() [2]float32 {
 	return w.offset
 }
 
+// LerpOffset interpolates the bobbing offset between the last and the current tic,
+// pulling the weapon up or down is not interpolated.
+func (w *Weapon) LerpOffset(frac float32) [2]float32 {
+	if w.state == 2 || w.state == 3 {
+		return w.offset
+	}
+	return [2]float32{
+		w.prevOffset[0] + (w.offset[0]-w.prevOffset[0])*frac,
+		w.prevOffset[1] + (w.offset[1]-w.prevOffset[1])*frac,
+	}
+}
+
+// AmmoType gets the ammunition the weapon uses.
+func (w *Weapon) AmmoType() AmmoType {
+	if t, ok := ammoNames[w.Ammo]; ok {
+		return t
+	}
+	return AmmoNone
+}
+
+func (w *Weapon) ammoPerShot() int {
+	if w.AmmoPerShot <= 0 {
+		return 1
+	}
+	return w.AmmoPerShot
+}
+
 // Fire fires the weapon
 func (w *Weapon) Fire() bool {
 	if w.state == 0 {
@@ -70,6 +101,7 @@ func (w *Weapon) pull(frameTime float64) {
 		w.offset[1] = 0
 		w.state = 0
 	}
+	w.prevOffset = w.offset
 }
 
 func (w *Weapon) bobbing() {
@@ -8,8 +8,8 @@ import (
 
 	"github.com/go-gl/mathgl/mgl32"
 
-	"github.com/tinogoehlert/goom/drivers"
 	"github.com/tinogoehlert/goom/goom"
+	"github.com/tinogoehlert/goom/graphics"
 	"github.com/tinogoehlert/goom/level"
 	"github.com/tinogoehlert/goom/utils"
 )
@@ -25,20 +25,31 @@ type World struct {
 	projectiles *list.List
 	me          *Player
 	levelRef    *level.Level
-	Audio       drivers.Audio
-	Music       drivers.Music
+	Audio       Audio
+	Music       Music
 	gameData    *goom.GameData
+	updates     int
+	buttons     []button
+	inSectors   sectorThings
+	inView      []Thingable
 }
 
+const (
+	// updatesPerSecond rate the window drivers call Update
+	updatesPerSecond = 60
+	// CollisionRadius distance things are kept away from walls
+	CollisionRadius = 16
+	// thingHitSize half the size of the box things are picked up in
+	thingHitSize = 24
+)
+
 // NewWorld Creates a new world.
 func NewWorld(data *goom.GameData, defs *DefStore) *World {
-	noop := drivers.NoopDrivers()
-
 	return &World{
 		definitions: defs,
 		gameData:    data,
-		Audio:       noop.Audio,
-		Music:       noop.Music,
+		Audio:       silence{},
+		Music:       silence{},
 	}
 }
 
@@ -52,9 +63,12 @@ func (w *World) Data() *goom.GameData {
 
 // LoadLevel a specific level of the world
 func (w *World) LoadLevel(lvl *level.Level) error {
-	w.nodes = lvl.Nodes(level.GLNodesName)
+	w.nodes = lvl.BspNodes()
 	w.levelRef = lvl
 	w.projectiles = list.New()
+	w.updates = 0
+	w.buttons = nil
+	w.inSectors = newSectorThings()
 
 	for _, t := range w.levelRef.Things {
 		if t.Type < 5 {
@@ -63,7 +77,7 @@ func (w *World) LoadLevel(lvl *level.Level) error {
 			if t.Type == 1 {
 				w.me = player
 			}
-			player.AddWeapon(w.definitions.GetWeapon("pistol"))
+			player.addWeapon(w.definitions.GetWeapon("pistol"))
 			player.SetCollision(w.doesCollide)
 		}
 		if obstacleDef := w.definitions.GetObstacleDef(int(t.Type)); obstacleDef != nil {
@@ -83,7 +97,7 @@ func (w *World) LoadLevel(lvl *level.Level) error {
 
 		if monsterDef := w.definitions.GetMonsterDef(int(t.Type)); monsterDef != nil {
 			sprite := w.gameData.Sprite(monsterDef.Sprite)
-			img := sprite.FirstFrame().Angles()[1]
+			img, _ := sprite.FirstFrame().Image(1)
 
 			monster := MonsterFromDef(
 				t.X,
@@ -98,6 +112,10 @@ func (w *World) LoadLevel(lvl *level.Level) error {
 		}
 	}
 
+	for _, t := range w.things {
+		w.inSectors.link(t)
+	}
+
 	mus := w.levelRef.Name
 	if w.levelRef.Name == "MAP01" {
 		mus = "THE_DA"
@@ -126,6 +144,32 @@ func (w *World) Things() []Thingable {
 	return w.things
 }
 
+// VisibleThings gets the things in the sectors of the visible subsectors
+// and the number of things culled.
+func (w *World) VisibleThings(vs *level.VisibleSet) ([]Thingable, int) {
+	if vs == nil || w.levelRef == nil {
+		return w.things, 0
+	}
+	w.inView = w.inView[:0]
+	for _, idx := range w.levelRef.VisibleSectors(vs) {
+		w.inView = append(w.inView, w.inSectors.in(idx)...)
+	}
+	w.inView = append(w.inView, w.inSectors.in(noSector)...)
+	return w.inView, len(w.things) - len(w.inView)
+}
+
+// Projectiles gets the flying projectiles.
+func (w *World) Projectiles() []*Projectile {
+	var projectiles []*Projectile
+	if w.projectiles == nil {
+		return nil
+	}
+	for e := w.projectiles.Front(); e != nil; e = e.Next() {
+		projectiles = append(projectiles, e.Value.(*Projectile))
+	}
+	return projectiles
+}
+
 // Monsters returns monsters
 func (w *World) Monsters() []*Monster {
 	if w == nil {
@@ -143,14 +187,34 @@ func (w *World) SetPlayer(num int) error {
 	return nil
 }
 
+// LevelTime gets the game tics since the level was loaded, see graphics.TicRate.
+func (w *World) LevelTime() int {
+	if w == nil {
+		return 0
+	}
+	return w.updates * graphics.TicRate / updatesPerSecond
+}
+
 // Update the world (monster, thing and player position)
 func (w *World) Update() {
+	w.saveStates()
+	tic := w.LevelTime()
+	w.updates++
+	if w.LevelTime() != tic {
+		for _, p := range w.players {
+			p.tick()
+		}
+	}
+	w.updateButtons()
 	ppos := utils.V2(w.me.position[0], w.me.position[1])
 	for _, m := range w.monsters {
 		if !m.IsCorpse() {
 			m.Update()
 			m.Think(w.me)
 		}
+		if m.position != m.prevPosition {
+			w.relink(m)
+		}
 	}
 	for e := w.projectiles.Front(); e != nil; e = e.Next() {
 		var (
@@ -195,6 +259,37 @@ func (w *World) Update() {
 	w.me.Update()
 }
 
+// saveStates keeps the state of everything that moves, the renderers
+// interpolate between it and the state after the update.
+func (w *World) saveStates() {
+	for _, p := range w.players {
+		p.saveState()
+	}
+	for _, t := range w.things {
+		if s, ok := t.(interface{ saveState() }); ok {
+			s.saveState()
+		}
+	}
+	for e := w.projectiles.Front(); e != nil; e = e.Next() {
+		e.Value.(*Projectile).saveState()
+	}
+	if w.levelRef != nil {
+		w.levelRef.SaveSectorHeights()
+	}
+}
+
+// relink finds the sector of a thing that moved and moves it to its list.
+func (w *World) relink(t Thingable) {
+	ssect, err := w.levelRef.FindPositionInBsp(level.GLNodesName, t.Position()[0], t.Position()[1])
+	if err != nil {
+		return
+	}
+	if sector := w.levelRef.SectorFromSSect(ssect); sector != nil {
+		t.SetSector(sector)
+	}
+	w.inSectors.relink(t)
+}
+
 func (w *World) doesCollide(thing *DoomThing, to mgl32.Vec2) mgl32.Vec2 {
 	w.checkThingCollision(thing, to)
 	return w.checkWallCollision(thing, to)
@@ -232,7 +327,7 @@ func (w *World) checkThingCollision(thing *DoomThing, to mgl32.Vec2) {
 			continue
 		}
 
-		if w.hitThing(thing, thing2, 24, 24) {
+		if w.hitThing(thing, thing2, thingHitSize, thingHitSize) {
 			switch t := thing2.(type) {
 			case *Item:
 				if t.category == "weapon" {
@@ -250,13 +345,12 @@ func (w *World) checkWallCollision(thing *DoomThing, to mgl32.Vec2) mgl32.Vec2 {
 	var (
 		x      = to.X()
 		y      = to.Y()
-		radius = float32(16)
+		radius = float32(CollisionRadius)
 	)
-	for _, wall := range w.levelRef.Walls {
-		if wall.IsTwoSided {
-			if wall.Sectors.Left.FloorHeight() < thing.currentSector.FloorHeight()+25 {
-				continue
-			}
+	for i := range w.levelRef.Walls {
+		wall := &w.levelRef.Walls[i]
+		if !BlocksMove(wall, thing.currentSector) {
+			continue
 		}
 		var (
 			d   = wall.Start.Dot(wall.Normal)
@@ -300,6 +394,23 @@ func (w *World) checkWallCollision(thing *DoomThing, to mgl32.Vec2) mgl32.Vec2 {
 	return to
 }
 
+// BlocksMove checks if a wall blocks things moving in the sector,
+// two-sided walls block steps higher than 24 units.
+func BlocksMove(wall *level.Wall, sector *level.Sector) bool {
+	if !wall.IsTwoSided {
+		return true
+	}
+	return wall.Sectors.Left.FloorHeight() >= sector.FloorHeight()+25
+}
+
+// HitBox gets the half width and height of the box a thing is hit in.
+func HitBox(t Thingable) (float32, float32) {
+	if m, ok := t.(*Monster); ok {
+		return m.sizeX, m.sizeY
+	}
+	return thingHitSize, thingHitSize
+}
+
 // GetLevel return the currently loaded level.
 func (w *World) GetLevel() *level.Level {
 	if w == nil {
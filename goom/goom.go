@@ -7,19 +7,26 @@ import (
 	"github.com/tinogoehlert/goom/audio/sfx"
 	"github.com/tinogoehlert/goom/graphics"
 	"github.com/tinogoehlert/goom/level"
+	"github.com/tinogoehlert/goom/utils"
 	"github.com/tinogoehlert/goom/wad"
 )
 
 //GameData Game Data
 type GameData struct {
-	Levels   level.Store
-	Textures graphics.TextureStore
-	Flats    graphics.FlatStore
-	Sprites  graphics.SpriteStore
-	Palettes *graphics.Palettes
-	Music    music.TrackStore
-	Sounds   sfx.Sounds
-	Fonts    graphics.FontBook
+	Levels     level.Store
+	MapInfo    level.MapInfo
+	Textures   graphics.TextureStore
+	Flats      graphics.FlatStore
+	Sprites    graphics.SpriteStore
+	Palettes   *graphics.Palettes
+	Colormap   *graphics.Colormap
+	TranMap    *graphics.TranMap
+	Animations *graphics.Animations
+	Switches   *graphics.Switches
+	Music      music.TrackStore
+	Sounds     sfx.Sounds
+	Fonts      graphics.FontBook
+	Graphics   graphics.GraphicStore
 }
 
 var (
@@ -56,48 +63,107 @@ func GetWAD(iwadfile, pwadfile string) (*GameData, error) {
 // LoadGameData loads engine data from WAD files.
 func LoadGameData(files ...string) (*GameData, error) {
 	gd := &GameData{
-		Levels:   level.NewStore(),
-		Textures: graphics.NewTextureStore(),
-		Flats:    graphics.NewFlatStore(),
-		Sprites:  graphics.NewSpriteStore(),
-		Music:    music.NewTrackStore(),
-		Sounds:   sfx.Sounds{},
-		Fonts:    graphics.NewFontBook(),
+		Levels:     level.NewStore(),
+		MapInfo:    level.NewMapInfo(),
+		Textures:   graphics.NewTextureStore(),
+		Flats:      graphics.NewFlatStore(),
+		Sprites:    graphics.NewSpriteStore(),
+		Music:      music.NewTrackStore(),
+		Sounds:     sfx.Sounds{},
+		Fonts:      graphics.NewFontBook(),
+		Graphics:   graphics.NewGraphicStore(),
+		Animations: graphics.NewAnimations(),
+		Switches:   graphics.NewSwitches(),
 	}
 	for _, file := range files {
 		wad, err := wad.NewWADFromFile(file)
 		if err != nil {
 			return nil, err
 		}
-		if err := gd.Levels.LoadWAD(wad); err != nil {
-			return nil, err
+		gd.Levels.LoadWAD(wad)
+		if err := gd.MapInfo.LoadWAD(wad); err != nil {
+			utils.GoomConsole.Print("%s, using the default map info", err.Error())
 		}
 		if p, _ := graphics.NewPalettes(wad); p != nil {
 			gd.Palettes = p
 		}
+		cm, err := graphics.NewColormap(wad)
+		if err != nil {
+			return nil, err
+		}
+		if cm != nil {
+			gd.Colormap = cm
+		}
+		tm, err := graphics.LoadTranMap(wad)
+		if err != nil {
+			return nil, err
+		}
+		if tm != nil {
+			gd.TranMap = tm
+		}
 		if err := gd.Fonts.LoadWAD(wad); err != nil {
 			return nil, err
 		}
 		gd.Sprites.LoadWAD(wad)
+		gd.Graphics.LoadWAD(wad)
 		gd.Flats.LoadWAD(wad)
 		gd.Textures.LoadWAD(wad)
+		if err := gd.Animations.LoadWAD(wad); err != nil {
+			return nil, err
+		}
+		if err := gd.Switches.LoadWAD(wad); err != nil {
+			return nil, err
+		}
 		gd.Music.LoadWAD(wad)
 		gd.Sounds.LoadWAD(wad)
 	}
 	gd.Textures.InitPatches()
+	gd.Animations.InitSequences()
+	gd.Switches.InitPairs(gd.Textures)
+	if gd.TranMap == nil && gd.Palettes != nil {
+		gd.TranMap = graphics.NewTranMap(gd.DefaultPalette(), graphics.TranslucencyAlpha)
+	}
 	return gd, nil
 }
 
+// LoadReplacementPack replaces textures, flats and sprites with the high
+// resolution PNG images of a directory or PK3 archive.
+func (gd *GameData) LoadReplacementPack(file string) error {
+	rp, err := graphics.LoadReplacementPack(file)
+	if err != nil {
+		return err
+	}
+	defer rp.Close()
+	rp.Apply(gd.Textures, gd.Flats, gd.Sprites)
+	return nil
+}
+
 // Level return level by name
 func (gd *GameData) Level(name string) *level.Level {
 	return gd.Levels[name]
 }
 
+// Sky gets the sky texture of a map, the vanilla default if
+// the map info names a missing texture, SKY1 if that is missing too.
+func (gd *GameData) Sky(mapName string) string {
+	for _, sky := range []string{gd.MapInfo.Sky(mapName), level.DefaultSky(mapName)} {
+		if _, ok := gd.Textures[sky]; ok {
+			return sky
+		}
+	}
+	return "SKY1"
+}
+
 // Texture return texture by name
 func (gd *GameData) Texture(name string) *graphics.Texture {
 	return gd.Textures[name]
 }
 
+// Graphic returns a full screen, status bar, menu or intermission graphic by name
+func (gd *GameData) Graphic(name string) *graphics.DoomPicture {
+	return gd.Graphics[name]
+}
+
 // Flat return flat(s) by name
 func (gd *GameData) Flat(name string) []*graphics.Flat {
 	return gd.Flats[name]
@@ -0,0 +1,59 @@
+package goom_test
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tinogoehlert/goom/goom"
+	"github.com/tinogoehlert/goom/test"
+)
+
+// writeWAD writes a PWAD of the lumps, given as name and data pairs.
+func writeWAD(t *testing.T, file string, lumps ...interface{}) {
+	var (
+		data = []byte{}
+		dir  = []byte{}
+	)
+	for i := 0; i < len(lumps); i += 2 {
+		var (
+			name  = lumps[i].(string)
+			lump  = lumps[i+1].([]byte)
+			entry = make([]byte, 16)
+		)
+		binary.LittleEndian.PutUint32(entry[0:4], uint32(12+len(data)))
+		binary.LittleEndian.PutUint32(entry[4:8], uint32(len(lump)))
+		copy(entry[8:], name)
+		data = append(data, lump...)
+		dir = append(dir, entry...)
+	}
+	header := make([]byte, 12)
+	copy(header, "PWAD")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(len(lumps)/2))
+	binary.LittleEndian.PutUint32(header[8:12], uint32(12+len(data)))
+	test.Check(ioutil.WriteFile(file, append(append(header, data...), dir...), 0644), t)
+}
+
+func TestPalettesKeptWithoutPlaypal(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "goom")
+	test.Check(err, t)
+	defer os.RemoveAll(tmp)
+
+	playpal := make([]byte, 14*256*3)
+	for i := range playpal {
+		playpal[i] = 200
+	}
+	var (
+		withPal    = filepath.Join(tmp, "pal.wad")
+		withoutPal = filepath.Join(tmp, "nopal.wad")
+	)
+	writeWAD(t, withPal, "PLAYPAL", playpal)
+	writeWAD(t, withoutPal, "DEMO1", []byte{0})
+
+	gd, err := goom.LoadGameData(withPal, withoutPal)
+	test.Check(err, t)
+	c := gd.DefaultPalette().Colors[1]
+	test.Assert(c.R == 200 && c.G == 200 && c.B == 200, "a WAD without PLAYPAL must keep the palettes", t)
+}
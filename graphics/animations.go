@@ -0,0 +1,251 @@
+package graphics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/tinogoehlert/goom/utils"
+	"github.com/tinogoehlert/goom/wad"
+)
+
+const (
+	// TicRate game tics per second, animation speeds are given in tics
+	TicRate = 35
+
+	animatedRecordSize = 23
+	animatedEnd        = 0xFF
+)
+
+// AnimDef defines an animated flat or wall texture by its first and last frame.
+// The frames are all flats or textures between both in WAD order.
+type AnimDef struct {
+	IsTexture bool
+	Start     string
+	End       string
+	// Speed tics each frame is shown
+	Speed int
+}
+
+// vanillaAnims the sequences hardcoded in vanilla, used without ANIMATED lump
+var vanillaAnims = []AnimDef{
+	{false, "NUKAGE1", "NUKAGE3", 8},
+	{false, "FWATER1", "FWATER4", 8},
+	{false, "SWATER1", "SWATER4", 8},
+	{false, "LAVA1", "LAVA4", 8},
+	{false, "BLOOD1", "BLOOD3", 8},
+
+	// DOOM II flat animations
+	{false, "RROCK05", "RROCK08", 8},
+	{false, "SLIME01", "SLIME04", 8},
+	{false, "SLIME05", "SLIME08", 8},
+	{false, "SLIME09", "SLIME12", 8},
+
+	{true, "BLODGR1", "BLODGR4", 8},
+	{true, "SLADRIP1", "SLADRIP3", 8},
+
+	{true, "BLODRIP1", "BLODRIP4", 8},
+	{true, "FIREWALA", "FIREWALL", 8},
+	{true, "GSTFONT1", "GSTFONT3", 8},
+	{true, "FIRELAV3", "FIRELAVA", 8},
+	{true, "FIREMAG1", "FIREMAG3", 8},
+	{true, "FIREBLU1", "FIREBLU2", 8},
+	{true, "ROCKRED1", "ROCKRED3", 8},
+
+	{true, "BFALL1", "BFALL4", 8},
+	{true, "SFALL1", "SFALL4", 8},
+	{true, "WFALL1", "WFALL4", 8},
+	{true, "DBRAIN1", "DBRAIN4", 8},
+}
+
+type animation struct {
+	frames []string
+	speed  int
+}
+
+// Animations registry of animated flats and wall textures.
+type Animations struct {
+	defs         []AnimDef
+	flatOrder    nameOrder
+	textureOrder nameOrder
+	flats        map[string]animFrame
+	textures     map[string]animFrame
+}
+
+// nameOrder lists names in the order they first appeared.
+type nameOrder struct {
+	names []string
+	index map[string]int
+}
+
+func (no *nameOrder) add(name string) {
+	if no.index == nil {
+		no.index = make(map[string]int)
+	}
+	if _, ok := no.index[name]; ok {
+		return
+	}
+	no.index[name] = len(no.names)
+	no.names = append(no.names, name)
+}
+
+func (no *nameOrder) indexOf(name string) int {
+	if i, ok := no.index[name]; ok {
+		return i
+	}
+	return -1
+}
+
+// animFrame is the position of a frame within its animation.
+type animFrame struct {
+	anim  *animation
+	index int
+}
+
+// NewAnimations creates a registry with the vanilla sequences.
+func NewAnimations() *Animations {
+	return &Animations{
+		defs:     append([]AnimDef{}, vanillaAnims...),
+		flats:    make(map[string]animFrame),
+		textures: make(map[string]animFrame),
+	}
+}
+
+// LoadWAD collects the order of flats and textures and reads the Boom ANIMATED lump,
+// which replaces the vanilla sequences. Call InitSequences once all WADs are loaded.
+func (a *Animations) LoadWAD(w *wad.WAD) error {
+	return a.loadLumps(w.Lumps())
+}
+
+func (a *Animations) loadLumps(lumps []wad.Lump) error {
+	inFlats := false
+	for i := range lumps {
+		lump := &lumps[i]
+		switch {
+		case flatStartRegex.MatchString(lump.Name):
+			inFlats = true
+		case flatEndRegex.MatchString(lump.Name):
+			inFlats = false
+		case inFlats && lump.Size > 0:
+			a.flatOrder.add(lump.Name)
+		case lump.Name == "TEXTURE1" || lump.Name == "TEXTURE2":
+			names, err := textureNames(lump)
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				a.textureOrder.add(name)
+			}
+		case lump.Name == "ANIMATED":
+			defs, err := parseAnimated(lump)
+			if err != nil {
+				return err
+			}
+			a.defs = defs
+		}
+	}
+	return nil
+}
+
+// InitSequences resolves the frames of all sequences, sequences with
+// a missing first or last frame are ignored like in vanilla.
+func (a *Animations) InitSequences() {
+	a.flats = make(map[string]animFrame)
+	a.textures = make(map[string]animFrame)
+	for _, def := range a.defs {
+		var (
+			order  = &a.flatOrder
+			frames = a.flats
+		)
+		if def.IsTexture {
+			order, frames = &a.textureOrder, a.textures
+		}
+		start, end := order.indexOf(def.Start), order.indexOf(def.End)
+		if start < 0 || end <= start || def.Speed <= 0 {
+			continue
+		}
+		anim := &animation{
+			frames: order.names[start : end+1],
+			speed:  def.Speed,
+		}
+		for i, name := range anim.frames {
+			frames[name] = animFrame{anim: anim, index: i}
+		}
+	}
+}
+
+// Flat gets the frame of the flat name shown at the game tic.
+func (a *Animations) Flat(name string, tic int) string {
+	if a == nil {
+		return name
+	}
+	return a.flats[name].frame(name, tic)
+}
+
+// Texture gets the frame of the wall texture name shown at the game tic.
+func (a *Animations) Texture(name string, tic int) string {
+	if a == nil {
+		return name
+	}
+	return a.textures[name].frame(name, tic)
+}
+
+func (af animFrame) frame(name string, tic int) string {
+	if af.anim == nil || tic < 0 {
+		return name
+	}
+	n := len(af.anim.frames)
+	return af.anim.frames[(tic/af.anim.speed+af.index)%n]
+}
+
+// parseAnimated reads the records of an ANIMATED lump.
+func parseAnimated(lump *wad.Lump) ([]AnimDef, error) {
+	defs := []AnimDef{}
+	for i := 0; ; i += animatedRecordSize {
+		if i >= len(lump.Data) {
+			return nil, fmt.Errorf("ANIMATED: missing end marker")
+		}
+		if lump.Data[i] == animatedEnd {
+			return defs, nil
+		}
+		if i+animatedRecordSize > len(lump.Data) {
+			return nil, fmt.Errorf("ANIMATED: size missmatch")
+		}
+		rec := lump.Data[i : i+animatedRecordSize]
+		defs = append(defs, AnimDef{
+			IsTexture: rec[0]&1 == 1,
+			End:       strings.ToUpper(cString(rec[1:10])),
+			Start:     strings.ToUpper(cString(rec[10:19])),
+			Speed:     int(int32(binary.LittleEndian.Uint32(rec[19:23]))),
+		})
+	}
+}
+
+// textureNames gets the names of the textures in a TEXTURE1/2 lump in order.
+func textureNames(lump *wad.Lump) ([]string, error) {
+	if len(lump.Data) < 4 {
+		return nil, fmt.Errorf("%s: size missmatch", lump.Name)
+	}
+	count := int(binary.LittleEndian.Uint32(lump.Data[0:4]))
+	if 4+count*4 > len(lump.Data) {
+		return nil, fmt.Errorf("%s: size missmatch", lump.Name)
+	}
+	names := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		offset := int(binary.LittleEndian.Uint32(lump.Data[4+i*4:]))
+		if offset+8 > len(lump.Data) {
+			return nil, fmt.Errorf("%s: texture %d out of range", lump.Name, i)
+		}
+		names = append(names, utils.WadString(lump.Data[offset:offset+8]))
+	}
+	return names, nil
+}
+
+// cString gets the string up to the first NUL byte.
+func cString(buff []byte) string {
+	if i := bytes.IndexByte(buff, 0); i >= 0 {
+		buff = buff[:i]
+	}
+	return string(buff)
+}
@@ -0,0 +1,87 @@
+package graphics
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/tinogoehlert/goom/test"
+	"github.com/tinogoehlert/goom/wad"
+)
+
+func animLump(name string, data []byte) wad.Lump {
+	return wad.Lump{Name: name, Size: len(data), Data: data}
+}
+
+func flatLumps(names ...string) []wad.Lump {
+	lumps := []wad.Lump{animLump("F_START", nil)}
+	for _, name := range names {
+		lumps = append(lumps, animLump(name, make([]byte, 64*64)))
+	}
+	return append(lumps, animLump("F_END", nil))
+}
+
+func animatedRecord(isTexture bool, start, end string, speed int32) []byte {
+	rec := make([]byte, animatedRecordSize)
+	if isTexture {
+		rec[0] = 1
+	}
+	copy(rec[1:10], end)
+	copy(rec[10:19], start)
+	binary.LittleEndian.PutUint32(rec[19:], uint32(speed))
+	return rec
+}
+
+func TestVanillaFlatAnimation(t *testing.T) {
+	a := NewAnimations()
+	test.Check(a.loadLumps(flatLumps("FLOOR4_8", "NUKAGE1", "NUKAGE2", "NUKAGE3")), t)
+	a.InitSequences()
+
+	test.Assert(a.Flat("NUKAGE1", 0) == "NUKAGE1", "expected first frame at tic 0", t)
+	test.Assert(a.Flat("NUKAGE1", 8) == "NUKAGE2", "expected second frame at tic 8", t)
+	test.Assert(a.Flat("NUKAGE3", 8) == "NUKAGE1", "expected sequence to wrap", t)
+	test.Assert(a.Flat("NUKAGE2", 24) == "NUKAGE2", "expected full cycle after 24 tics", t)
+	test.Assert(a.Flat("FLOOR4_8", 8) == "FLOOR4_8", "static flat must not change", t)
+	test.Assert(a.Texture("NUKAGE1", 8) == "NUKAGE1", "flat sequence used for texture", t)
+}
+
+func TestPWADFlatNamespace(t *testing.T) {
+	lumps := []wad.Lump{animLump("FF_START", nil)}
+	for _, name := range []string{"NUKAGE1", "NUKAGE2", "NUKAGE3"} {
+		lumps = append(lumps, animLump(name, make([]byte, 64*64)))
+	}
+	lumps = append(lumps, animLump("FF_END", nil))
+
+	a := NewAnimations()
+	test.Check(a.loadLumps(lumps), t)
+	a.InitSequences()
+	test.Assert(a.Flat("NUKAGE1", 8) == "NUKAGE2", "expected the flats between FF_START and FF_END", t)
+
+	fs := NewFlatStore()
+	fs.loadLumps(lumps)
+	test.Assert(len(fs) == 3 && fs["NUKAGE2"] != nil, "expected the flats of the PWAD namespace", t)
+}
+
+func TestAnimatedLump(t *testing.T) {
+	data := animatedRecord(false, "SLIME1", "SLIME2", 4)
+	data = append(data, 0xFF)
+
+	a := NewAnimations()
+	lumps := flatLumps("NUKAGE1", "NUKAGE2", "NUKAGE3", "SLIME1", "SLIME2")
+	lumps = append(lumps, animLump("ANIMATED", data))
+	test.Check(a.loadLumps(lumps), t)
+	a.InitSequences()
+
+	test.Assert(a.Flat("SLIME1", 4) == "SLIME2", "expected ANIMATED sequence", t)
+	test.Assert(a.Flat("NUKAGE1", 8) == "NUKAGE1", "ANIMATED must replace vanilla sequences", t)
+}
+
+func TestAnimatedLumpWithoutEnd(t *testing.T) {
+	a := NewAnimations()
+	lumps := []wad.Lump{animLump("ANIMATED", animatedRecord(true, "A1", "A2", 8))}
+	test.Assert(a.loadLumps(lumps) != nil, "expected error for missing end marker", t)
+}
+
+func TestNilAnimations(t *testing.T) {
+	var a *Animations
+	test.Assert(a.Texture("BLODGR1", 8) == "BLODGR1", "nil registry must return the name", t)
+}
@@ -0,0 +1,188 @@
+package graphics
+
+import (
+	"image"
+	"sort"
+)
+
+// atlasPadding transparent pixels between two images of a page, which keeps
+// filtering from bleeding into the neighbours
+const atlasPadding = 1
+
+// AtlasRegion is the place of an image in an atlas.
+type AtlasRegion struct {
+	Page int
+	// Rect pixels of the image in the page
+	Rect image.Rectangle
+	// U0, V0, U1, V1 texture coordinates of the image's corners
+	U0, V0, U1, V1 float32
+}
+
+// AtlasPage is a packed image, either true color or palette indices.
+type AtlasPage struct {
+	RGBA    *image.RGBA
+	Indexed *image.Paletted
+}
+
+// Atlas holds images packed into a few large pages.
+type Atlas struct {
+	Pages   []AtlasPage
+	regions map[string]AtlasRegion
+}
+
+// Region gets the place of the image added with the key.
+func (a *Atlas) Region(key string) (AtlasRegion, bool) {
+	r, ok := a.regions[key]
+	return r, ok
+}
+
+type atlasEntry struct {
+	key  string
+	img  Image
+	size image.Point
+}
+
+// AtlasBuilder collects images and packs them into pages.
+type AtlasBuilder struct {
+	pageSize int
+	indexed  bool
+	palette  Palette
+	entries  []atlasEntry
+	// keys of the added images, images added again share their region
+	keys    map[Image]string
+	aliases map[string]string
+}
+
+// NewAtlasBuilder creates a builder for square pages of pageSize pixels. Indexed
+// pages hold palette indices, true color pages prefer high resolution replacements.
+func NewAtlasBuilder(pageSize int, indexed bool, palette Palette) *AtlasBuilder {
+	return &AtlasBuilder{
+		pageSize: pageSize,
+		indexed:  indexed,
+		palette:  palette,
+		keys:     make(map[Image]string),
+		aliases:  make(map[string]string),
+	}
+}
+
+// Add adds an image with a key to look up its region, nil images are ignored.
+// An image added with several keys is packed once.
+func (ab *AtlasBuilder) Add(key string, img Image) {
+	if img == nil {
+		return
+	}
+	if p, ok := img.(*DoomPicture); ok && p == nil {
+		return
+	}
+	if first, ok := ab.keys[img]; ok {
+		ab.aliases[key] = first
+		return
+	}
+	size := image.Pt(img.Width(), img.Height())
+	if hr, ok := img.(HiResImage); ok && !ab.indexed && hr.HiRes() != nil {
+		size = hr.HiRes().Bounds().Size()
+	}
+	if size.X <= 0 || size.Y <= 0 {
+		return
+	}
+	ab.keys[img] = key
+	ab.entries = append(ab.entries, atlasEntry{key: key, img: img, size: size})
+}
+
+// Build packs the images in rows of pages, the tallest images first.
+// Images larger than a page get a page of their own.
+func (ab *AtlasBuilder) Build() *Atlas {
+	entries := append([]atlasEntry{}, ab.entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].size.Y != entries[j].size.Y {
+			return entries[i].size.Y > entries[j].size.Y
+		}
+		return entries[i].key < entries[j].key
+	})
+
+	var (
+		atlas   = &Atlas{regions: make(map[string]AtlasRegion)}
+		places  = make([]image.Point, len(entries))
+		pages   = make([]int, len(entries))
+		sizes   = []image.Point{}
+		page    = -1
+		x, y, h = 0, 0, 0
+	)
+	for i, e := range entries {
+		w := e.size.X + atlasPadding
+		if e.size.X > ab.pageSize || e.size.Y > ab.pageSize {
+			// oversized, alone on its page
+			pages[i] = len(sizes)
+			sizes = append(sizes, e.size)
+			continue
+		}
+		if page >= 0 && x+w > ab.pageSize {
+			x, y, h = 0, y+h, 0
+		}
+		if page < 0 || y+e.size.Y > ab.pageSize {
+			page = len(sizes)
+			sizes = append(sizes, image.Pt(ab.pageSize, ab.pageSize))
+			x, y, h = 0, 0, 0
+		}
+		places[i] = image.Pt(x, y)
+		pages[i] = page
+		x += w
+		if e.size.Y+atlasPadding > h {
+			h = e.size.Y + atlasPadding
+		}
+	}
+
+	for _, size := range sizes {
+		atlas.Pages = append(atlas.Pages, ab.newPage(size))
+	}
+	for i, e := range entries {
+		var (
+			rect = image.Rectangle{places[i], places[i].Add(e.size)}
+			size = sizes[pages[i]]
+		)
+		ab.draw(atlas.Pages[pages[i]], rect, e.img)
+		atlas.regions[e.key] = AtlasRegion{
+			Page: pages[i],
+			Rect: rect,
+			U0:   float32(rect.Min.X) / float32(size.X),
+			V0:   float32(rect.Min.Y) / float32(size.Y),
+			U1:   float32(rect.Max.X) / float32(size.X),
+			V1:   float32(rect.Max.Y) / float32(size.Y),
+		}
+	}
+	for key, first := range ab.aliases {
+		atlas.regions[key] = atlas.regions[first]
+	}
+	return atlas
+}
+
+func (ab *AtlasBuilder) newPage(size image.Point) AtlasPage {
+	bounds := image.Rectangle{Max: size}
+	if !ab.indexed {
+		return AtlasPage{RGBA: image.NewRGBA(bounds)}
+	}
+	page := image.NewPaletted(bounds, colorPalette(ab.palette.Colors))
+	for i := range page.Pix {
+		page.Pix[i] = transparentColor
+	}
+	return AtlasPage{Indexed: page}
+}
+
+// draw copies the image into the rectangle of the page.
+func (ab *AtlasBuilder) draw(page AtlasPage, rect image.Rectangle, img Image) {
+	if page.Indexed != nil {
+		src := img.ToPaletted(ab.palette.Colors)
+		for y := 0; y < rect.Dy(); y++ {
+			copy(page.Indexed.Pix[page.Indexed.PixOffset(rect.Min.X, rect.Min.Y+y):], src.Pix[y*src.Stride:y*src.Stride+rect.Dx()])
+		}
+		return
+	}
+	src := TrueColor(img, ab.palette.Colors)
+	for y := 0; y < rect.Dy(); y++ {
+		var (
+			from = src.PixOffset(src.Rect.Min.X, src.Rect.Min.Y+y)
+			to   = page.RGBA.PixOffset(rect.Min.X, rect.Min.Y+y)
+		)
+		copy(page.RGBA.Pix[to:to+rect.Dx()*4], src.Pix[from:from+rect.Dx()*4])
+	}
+}
@@ -0,0 +1,82 @@
+package graphics
+
+import (
+	"fmt"
+	"image"
+	"testing"
+
+	"github.com/tinogoehlert/goom/test"
+)
+
+func filledPicture(width, height int, index uint8) *DoomPicture {
+	p := newDummyPicture(width, height)
+	for i := range p.data {
+		p.data[i] = index
+	}
+	return p
+}
+
+func TestAtlasPacking(t *testing.T) {
+	ab := NewAtlasBuilder(64, true, Palette{})
+	for i := 0; i < 6; i++ {
+		ab.Add(fmt.Sprintf("P%d", i), filledPicture(20, 10+i, uint8(i)))
+	}
+	ab.Add("NIL", (*DoomPicture)(nil))
+	ab.Add("HUGE", filledPicture(100, 20, 9))
+	shared := filledPicture(8, 8, 7)
+	ab.Add("SHARED1", shared)
+	ab.Add("SHARED2", shared)
+	atlas := ab.Build()
+
+	_, ok := atlas.Region("NIL")
+	test.Assert(!ok, "nil images must be skipped", t)
+
+	huge, ok := atlas.Region("HUGE")
+	test.Assert(ok && huge.Rect == image.Rect(0, 0, 100, 20), "oversized image needs its own page", t)
+	test.Assert(atlas.Pages[huge.Page].Indexed.Rect.Dx() == 100, "expected page of the image's size", t)
+
+	s1, ok1 := atlas.Region("SHARED1")
+	s2, ok2 := atlas.Region("SHARED2")
+	test.Assert(ok1 && ok2 && s1 == s2, "an image added twice must share its region", t)
+
+	regions := []AtlasRegion{}
+	for i := 0; i < 6; i++ {
+		r, ok := atlas.Region(fmt.Sprintf("P%d", i))
+		test.Assert(ok, "expected region", t)
+		test.Assert(r.Rect.Dx() == 20 && r.Rect.Dy() == 10+i, "region must have the image's size", t)
+		test.Assert(r.Rect.Max.X <= 64 && r.Rect.Max.Y <= 64, "region must be inside the page", t)
+		page := atlas.Pages[r.Page].Indexed
+		test.Assert(page.ColorIndexAt(r.Rect.Min.X, r.Rect.Min.Y) == uint8(i), "expected image pixels", t)
+		test.Assert(page.ColorIndexAt(r.Rect.Max.X, r.Rect.Min.Y) != uint8(i), "expected padding", t)
+		test.Assert(r.U1 == float32(r.Rect.Max.X)/64 && r.V0 == float32(r.Rect.Min.Y)/64, "unexpected texture coordinates", t)
+		regions = append(regions, r)
+	}
+	for i := range regions {
+		for j := range regions {
+			if i != j && regions[i].Page == regions[j].Page {
+				test.Assert(!regions[i].Rect.Overlaps(regions[j].Rect), "regions must not overlap", t)
+			}
+		}
+	}
+	test.Assert(len(atlas.Pages) == 2, fmt.Sprintf("expected two pages, got %d", len(atlas.Pages)), t)
+}
+
+func TestAtlasTrueColor(t *testing.T) {
+	var (
+		palette = Palette{}
+		pic     = filledPicture(4, 4, 1)
+	)
+	palette.Colors[1].R, palette.Colors[1].A = 200, 255
+	ab := NewAtlasBuilder(32, false, palette)
+	ab.Add("A", pic)
+	atlas := ab.Build()
+	r, _ := atlas.Region("A")
+	test.Assert(atlas.Pages[0].RGBA.RGBAAt(r.Rect.Min.X, r.Rect.Min.Y).R == 200, "expected converted color", t)
+
+	hires := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	pic.SetHiRes(hires)
+	ab = NewAtlasBuilder(32, false, palette)
+	ab.Add("A", pic)
+	r, _ = ab.Build().Region("A")
+	test.Assert(r.Rect.Dx() == 16, "expected the replacement to be packed", t)
+}
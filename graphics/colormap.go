@@ -0,0 +1,107 @@
+package graphics
+
+import (
+	"fmt"
+
+	"github.com/tinogoehlert/goom/wad"
+)
+
+const (
+	// NumColormaps number of maps in the COLORMAP lump
+	NumColormaps = 34
+	// NumLightColormaps number of maps used for light levels, 0 is the brightest
+	NumLightColormaps = 32
+	// InvulnerabilityColormap greyscale map used by the invulnerability sphere
+	InvulnerabilityColormap = 32
+
+	lightLevels   = 16
+	lightSegShift = 4
+	maxLightZ     = 128
+	lightZShift   = 4
+	distMap       = 2
+	// centerX half of the original screen width, used by the distance fade
+	centerX = 160
+	// hudLightScale scale of the light map used for weapon sprites
+	hudLightScale = 47
+)
+
+// Colormap maps palette indices to darker palette indices for every light level.
+type Colormap [NumColormaps][256]uint8
+
+// NewColormap reads the COLORMAP lump, nil is returned if the WAD has none.
+func NewColormap(w *wad.WAD) (*Colormap, error) {
+	lump := w.Lump("COLORMAP")
+	if lump == nil {
+		return nil, nil
+	}
+	if len(lump.Data) < NumColormaps*256 {
+		return nil, fmt.Errorf("COLORMAP: size missmatch")
+	}
+	var cm Colormap
+	for i := 0; i < NumColormaps; i++ {
+		copy(cm[i][:], lump.Data[i*256:(i+1)*256])
+	}
+	return &cm, nil
+}
+
+// Map gets the map with the given index, 0 - 31 for light levels, 32 for invulnerability.
+func (cm *Colormap) Map(index int) *[256]uint8 {
+	if index < 0 {
+		index = 0
+	}
+	if index >= NumColormaps {
+		index = NumColormaps - 1
+	}
+	return &cm[index]
+}
+
+// Invulnerability gets the greyscale map of the invulnerability sphere.
+func (cm *Colormap) Invulnerability() *[256]uint8 {
+	return &cm[InvulnerabilityColormap]
+}
+
+// Light applies a light level and distance to a palette index.
+func (cm *Colormap) Light(index uint8, light, dist float32) uint8 {
+	return cm[LightIndex(light, dist)][index]
+}
+
+// LightIndex gets the colormap index for a sector light level (0 - 255)
+// and a distance to the viewer in map units, the same way vanilla fades flats.
+func LightIndex(light, dist float32) int {
+	z := int(dist) >> lightZShift
+	if z < 0 {
+		z = 0
+	}
+	if z >= maxLightZ {
+		z = maxLightZ - 1
+	}
+	scale := centerX / (z + 1)
+	return clampLightIndex(startMap(light) - scale/distMap)
+}
+
+// HUDLightIndex gets the colormap index for weapon sprites in a sector with the light level.
+func HUDLightIndex(light float32) int {
+	return clampLightIndex(startMap(light) - hudLightScale/distMap)
+}
+
+// startMap gets the colormap used for a light level at the farthest distance.
+func startMap(light float32) int {
+	l := int(light) >> lightSegShift
+	if l < 0 {
+		l = 0
+	}
+	if l >= lightLevels {
+		l = lightLevels - 1
+	}
+	return (lightLevels - 1 - l) * 2 * NumLightColormaps / lightLevels
+}
+
+func clampLightIndex(index int) int {
+	if index < 0 {
+		return 0
+	}
+	if index >= NumLightColormaps {
+		return NumLightColormaps - 1
+	}
+	return index
+}
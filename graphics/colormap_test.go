@@ -0,0 +1,16 @@
+package graphics_test
+
+import (
+	"testing"
+
+	"github.com/tinogoehlert/goom/graphics"
+	"github.com/tinogoehlert/goom/test"
+)
+
+func TestLightIndex(t *testing.T) {
+	test.Assert(graphics.LightIndex(255, 0) == 0, "full light near the viewer must use map 0", t)
+	test.Assert(graphics.LightIndex(0, 5000) == 31, "darkness far away must use map 31", t)
+	test.Assert(graphics.LightIndex(160, 2000) > graphics.LightIndex(160, 100), "light must fade with distance", t)
+	test.Assert(graphics.LightIndex(96, 500) > graphics.LightIndex(192, 500), "darker sectors must use darker maps", t)
+	test.Assert(graphics.HUDLightIndex(255) == 0, "weapon in full light must use map 0", t)
+}
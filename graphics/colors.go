@@ -8,6 +8,8 @@ import (
 
 const (
 	numPalettes = 14
+	// paletteSize bytes of a palette in PLAYPAL
+	paletteSize = 256 * 3
 )
 
 // Palette DOOM color palette
@@ -20,18 +22,24 @@ var defaultPalette Palette
 // Palettes one or more palettes
 type Palettes [numPalettes]Palette
 
-// NewPalettes one or more palettes
+// NewPalettes one or more palettes, nil if the WAD has no PLAYPAL.
 func NewPalettes(w *wad.WAD) (*Palettes, error) {
 	var palettes = Palettes{}
 	for _, lump := range w.Lumps() {
 		if lump.Name == "PLAYPAL" {
 			for i := 0; i < numPalettes; i++ {
 				p := Palette{}
-				for ci := 0; ci < 256*3; ci += 3 {
-					p.Colors[ci/3].R = lump.Data[ci]
-					p.Colors[ci/3].G = lump.Data[ci+1]
-					p.Colors[ci/3].B = lump.Data[ci+2]
-					p.Colors[ci/3].A = 255
+				offset := i * paletteSize
+				if offset+paletteSize > len(lump.Data) {
+					// some PWADs only replace the first palettes
+					p = palettes[0]
+				} else {
+					for ci := 0; ci < paletteSize; ci += 3 {
+						p.Colors[ci/3].R = lump.Data[offset+ci]
+						p.Colors[ci/3].G = lump.Data[offset+ci+1]
+						p.Colors[ci/3].B = lump.Data[offset+ci+2]
+						p.Colors[ci/3].A = 255
+					}
 				}
 				if i == 0 {
 					defaultPalette = p
@@ -41,7 +49,7 @@ func NewPalettes(w *wad.WAD) (*Palettes, error) {
 			return &palettes, nil
 		}
 	}
-	return &palettes, nil
+	return nil, nil
 }
 
 func DefaultPalette() Palette {
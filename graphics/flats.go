@@ -6,6 +6,13 @@ import (
 	"github.com/tinogoehlert/goom/wad"
 )
 
+var (
+	// flatStartRegex and flatEndRegex match the markers of the flat namespace,
+	// F_START in IWADs and FF_START in most PWADs
+	flatStartRegex = regexp.MustCompile(`^F{1,2}_START$`)
+	flatEndRegex   = regexp.MustCompile(`^F{1,2}_END$`)
+)
+
 // Flat floor / ceiling texture
 type Flat struct {
 	*DoomPicture
@@ -18,12 +25,16 @@ func NewFlat(name string, buff []byte) *Flat {
 		name: name,
 	}
 
-	f.DoomPicture = newDummyPicture(64, 64)
-
-	for i, b := range buff {
-		f.DoomPicture.data[i] = b
+	if isModernImage(buff) {
+		if p, err := newModernPicture(buff, defaultPalette.Colors); err == nil {
+			f.DoomPicture = p
+			return f
+		}
 	}
 
+	f.DoomPicture = newDummyPicture(64, 64)
+	copy(f.DoomPicture.data, buff)
+
 	return f
 }
 
@@ -35,12 +46,10 @@ func NewFlatStore() FlatStore {
 }
 
 func (fs FlatStore) LoadWAD(w *wad.WAD) {
-	var (
-		flatStartRegex = regexp.MustCompile(`^F?_START`)
-		flatEndRegex   = regexp.MustCompile(`^F?_END`)
-		lumps          = w.Lumps()
-	)
+	fs.loadLumps(w.Lumps())
+}
 
+func (fs FlatStore) loadLumps(lumps []wad.Lump) {
 	for i := 0; i < len(lumps); i++ {
 		lump := &lumps[i]
 		if flatStartRegex.Match([]byte(lump.Name)) {
@@ -43,53 +43,61 @@ var (
 )
 
 // Glyph is a DoomPicture with some font identifying meta data
-type glyph struct {
+type Glyph struct {
 	*DoomPicture
 	name string
 	char rune
 }
 
-func newGlyph(name string, char rune, buff []byte) glyph {
-	return glyph{
+func newGlyph(name string, char rune, buff []byte) Glyph {
+	return Glyph{
 		DoomPicture: NewDoomPicture(buff),
 		name:        name,
 		char:        char,
 	}
 }
 
-func (g *glyph) GetName() string {
+// GetName gets the name of the glyph's lump
+func (g *Glyph) GetName() string {
 	return g.name
 }
 
-// font is a collection of glyphs
-type font struct {
+// Char gets the character the glyph shows
+func (g *Glyph) Char() rune {
+	return g.char
+}
+
+// Font is a collection of glyphs
+type Font struct {
 	name         FontName
 	spacing      int
 	fallback     rune
-	glyphNameMap map[string]glyph
-	glyphRuneMap map[rune]*glyph
+	glyphNameMap map[string]Glyph
+	glyphRuneMap map[rune]*Glyph
 }
 
-func newFont(name FontName, spacing int, fallback rune) font {
-	return font{
+func newFont(name FontName, spacing int, fallback rune) Font {
+	return Font{
 		name:         name,
 		spacing:      spacing,
 		fallback:     fallback,
-		glyphNameMap: make(map[string]glyph),
-		glyphRuneMap: make(map[rune]*glyph),
+		glyphNameMap: make(map[string]Glyph),
+		glyphRuneMap: make(map[rune]*Glyph),
 	}
 }
 
-func (f font) addGlyph(g glyph) {
+func (f Font) addGlyph(g Glyph) {
 	f.glyphNameMap[g.name] = g
 	f.glyphRuneMap[g.char] = &g
 }
 
-func (f *font) GetSpacing() int {
+// GetSpacing gets the fixed spacing of the font
+func (f Font) GetSpacing() int {
 	return f.spacing
 }
 
-func (f *font) GetGlyph(r rune) *glyph {
+// GetGlyph gets the glyph of the rune, or the glyph of the font's fallback rune
+func (f Font) GetGlyph(r rune) *Glyph {
 	g := f.glyphRuneMap[r]
 	if g == nil {
 		return f.glyphRuneMap[f.fallback]
@@ -98,8 +106,25 @@ func (f *font) GetGlyph(r rune) *glyph {
 	return g
 }
 
+// Glyph gets the glyph of the rune without falling back
+func (f Font) Glyph(r rune) (*Glyph, bool) {
+	g, ok := f.glyphRuneMap[r]
+	return g, ok && g.DoomPicture != nil
+}
+
+// Height gets the height of the font's tallest glyph
+func (f Font) Height() int {
+	h := 0
+	for _, g := range f.glyphRuneMap {
+		if g.DoomPicture != nil && g.Height() > h {
+			h = g.Height()
+		}
+	}
+	return h
+}
+
 // FontBook is a collection of fonts
-type FontBook map[FontName]font
+type FontBook map[FontName]Font
 
 // NewFontBook initializes a new fontBook with the defined fonts
 func NewFontBook() FontBook {
@@ -113,10 +138,10 @@ func NewFontBook() FontBook {
 }
 
 // getFont returns a font from the fontBook
-func (fb *FontBook) getFont(name FontName) (*font, error) {
+func (fb *FontBook) getFont(name FontName) (*Font, error) {
 	f, ok := (*fb)[name]
 	if !ok {
-		return &font{}, fmt.Errorf("font not found: %v", FontName(name))
+		return &Font{}, fmt.Errorf("font not found: %v", FontName(name))
 	}
 
 	return &f, nil
@@ -0,0 +1,46 @@
+package graphics
+
+import (
+	"regexp"
+
+	"github.com/tinogoehlert/goom/wad"
+)
+
+// graphicRegex matches the names of picture lumps outside of the namespaces:
+// title, help and end screens, status bar, menu and intermission graphics
+var graphicRegex = regexp.MustCompile(`^(TITLEPIC|CREDIT|HELP\d?|VICTORY2|ENDPIC|INTERPIC|BOSSBACK|PFUB[12]|END[0-6]|` +
+	`STBAR|STARMS|STF\w+|STT\w+|STYS\w+|STG\w+|STK\w+|STC\w+|STDISK|STCDROM|AMMNUM\d|BRDR_\w+|M_\w+|WI\w+|CWILV\d\d)$`)
+
+var (
+	namespaceStartRegex = regexp.MustCompile(`^[SFP]{1,2}_START$`)
+	namespaceEndRegex   = regexp.MustCompile(`^[SFP]{1,2}_END$`)
+)
+
+// GraphicStore stores full screen, status bar, menu and intermission graphics by name.
+type GraphicStore map[string]*DoomPicture
+
+// NewGraphicStore creates a new graphic store
+func NewGraphicStore() GraphicStore {
+	return make(GraphicStore)
+}
+
+// LoadWAD loads the graphic lumps of a WAD, lumps that can't be decoded are skipped.
+func (gs GraphicStore) LoadWAD(w *wad.WAD) {
+	gs.loadLumps(w.Lumps())
+}
+
+func (gs GraphicStore) loadLumps(lumps []wad.Lump) {
+	inNamespace := false
+	for _, lump := range lumps {
+		switch {
+		case namespaceStartRegex.MatchString(lump.Name):
+			inNamespace = true
+		case namespaceEndRegex.MatchString(lump.Name):
+			inNamespace = false
+		case !inNamespace && lump.Size > 0 && graphicRegex.MatchString(lump.Name):
+			if pic, err := NewPicture(lump.Data); err == nil {
+				gs[lump.Name] = pic
+			}
+		}
+	}
+}
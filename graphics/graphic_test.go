@@ -0,0 +1,42 @@
+package graphics
+
+import (
+	"testing"
+
+	"github.com/tinogoehlert/goom/test"
+	"github.com/tinogoehlert/goom/wad"
+)
+
+// 1x1 picture with a single opaque pixel
+func testPictureLump(name string) wad.Lump {
+	data := []byte{
+		1, 0, 1, 0, 0, 0, 0, 0,
+		12, 0, 0, 0,
+		0, 1, 0, 42, 0, 255,
+	}
+	return wad.Lump{Name: name, Size: len(data), Data: data}
+}
+
+func TestGraphicStore(t *testing.T) {
+	gs := NewGraphicStore()
+	gs.loadLumps([]wad.Lump{
+		testPictureLump("TITLEPIC"),
+		testPictureLump("STBAR"),
+		testPictureLump("M_DOOM"),
+		testPictureLump("WIMAP0"),
+		testPictureLump("PLAYPAL"),
+		{Name: "S_START"},
+		testPictureLump("STFST01"),
+		{Name: "S_END"},
+		{Name: "HELP1", Size: 3, Data: []byte{1, 2, 3}},
+	})
+	for _, name := range []string{"TITLEPIC", "STBAR", "M_DOOM", "WIMAP0"} {
+		test.Assert(gs[name] != nil, name+" not loaded", t)
+	}
+	test.Assert(gs["PLAYPAL"] == nil, "PLAYPAL is no graphic", t)
+	test.Assert(gs["STFST01"] == nil, "sprite namespace must be skipped", t)
+	test.Assert(gs["HELP1"] == nil, "broken picture must be skipped", t)
+	if pic := gs["STBAR"]; pic != nil {
+		test.Assert(pic.data[0] == 42, "wrong pixel", t)
+	}
+}
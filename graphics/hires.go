@@ -0,0 +1,241 @@
+package graphics
+
+import (
+	"archive/zip"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/tinogoehlert/goom/utils"
+)
+
+// replacementCategory namespace of the images a replacement may replace
+type replacementCategory int
+
+const (
+	// anyCategory replacements outside of a category directory replace
+	// textures, flats and sprites
+	anyCategory replacementCategory = iota
+	textureCategory
+	flatCategory
+	spriteCategory
+)
+
+// replacementDirs directory names of the categories, the ZDoom hires
+// directory replaces images of all categories
+var replacementDirs = map[string]replacementCategory{
+	"hires":    anyCategory,
+	"textures": textureCategory,
+	"walls":    textureCategory,
+	"flats":    flatCategory,
+	"sprites":  spriteCategory,
+}
+
+// HiResImage is an image that may have a true color replacement.
+type HiResImage interface {
+	HiRes() *image.RGBA
+	SetHiRes(img *image.RGBA)
+}
+
+// hiRes holds the true color replacement of an image. The replacement
+// covers the image's original size, so it only changes the detail.
+type hiRes struct {
+	hires *image.RGBA
+}
+
+// HiRes gets the replacement, nil without one.
+func (h *hiRes) HiRes() *image.RGBA {
+	return h.hires
+}
+
+// SetHiRes sets the replacement.
+func (h *hiRes) SetHiRes(img *image.RGBA) {
+	h.hires = img
+}
+
+// TrueColor gets the replacement of the image, or converts it with the palette without one.
+func TrueColor(img Image, palette [256]color.RGBA) *image.RGBA {
+	if hr, ok := img.(HiResImage); ok && hr.HiRes() != nil {
+		return hr.HiRes()
+	}
+	return img.ToRGBA(palette)
+}
+
+// replacement is an image file of a replacement pack.
+type replacement struct {
+	file string
+	open func() (io.ReadCloser, error)
+}
+
+// ReplacementPack maps texture, flat and sprite names to high resolution
+// PNG files of a directory or a PK3 archive.
+type ReplacementPack struct {
+	images  map[replacementCategory]map[string]replacement
+	archive *zip.ReadCloser
+}
+
+// LoadReplacementPack indexes the PNG files of a directory or PK3/ZIP archive.
+// Files are named after the image they replace, e.g. textures/STARTAN3.png.
+func LoadReplacementPack(file string) (*ReplacementPack, error) {
+	rp := &ReplacementPack{images: make(map[replacementCategory]map[string]replacement)}
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not open replacement pack: %s", err.Error())
+	}
+	if info.IsDir() {
+		err = filepath.Walk(file, func(p string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(file, p)
+			if err != nil {
+				return err
+			}
+			rp.add(filepath.ToSlash(rel), func() (io.ReadCloser, error) {
+				return os.Open(p)
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not read replacement pack: %s", err.Error())
+		}
+		return rp, nil
+	}
+
+	rp.archive, err = zip.OpenReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not open replacement pack: %s", err.Error())
+	}
+	for _, f := range rp.archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rp.add(f.Name, f.Open)
+	}
+	return rp, nil
+}
+
+// add indexes a file by the name of the image it replaces.
+func (rp *ReplacementPack) add(file string, open func() (io.ReadCloser, error)) {
+	if !strings.EqualFold(path.Ext(file), ".png") {
+		return
+	}
+	var (
+		name     = strings.ToUpper(strings.TrimSuffix(path.Base(file), path.Ext(file)))
+		category = anyCategory
+	)
+	for _, dir := range strings.Split(path.Dir(file), "/") {
+		if c, ok := replacementDirs[strings.ToLower(dir)]; ok && c != anyCategory {
+			category = c
+		}
+	}
+	if rp.images[category] == nil {
+		rp.images[category] = make(map[string]replacement)
+	}
+	rp.images[category][name] = replacement{file: file, open: open}
+}
+
+// Close closes the archive of the pack.
+func (rp *ReplacementPack) Close() error {
+	if rp.archive == nil {
+		return nil
+	}
+	return rp.archive.Close()
+}
+
+// lookup finds the replacement of an image in its category or in the general one.
+func (rp *ReplacementPack) lookup(category replacementCategory, name string) (replacement, bool) {
+	if r, ok := rp.images[category][name]; ok {
+		return r, true
+	}
+	r, ok := rp.images[anyCategory][name]
+	return r, ok
+}
+
+// Apply sets the replacements of the stores' images. Sizes and offsets stay
+// the original ones, so the world and the collision detection are unchanged.
+// Images whose file can't be decoded keep their original.
+func (rp *ReplacementPack) Apply(textures TextureStore, flats FlatStore, sprites SpriteStore) {
+	for name, tex := range textures {
+		rp.replace(textureCategory, name, tex)
+	}
+	for name, fl := range flats {
+		for _, f := range fl {
+			rp.replace(flatCategory, name, f)
+		}
+	}
+	for _, category := range []replacementCategory{anyCategory, spriteCategory} {
+		for name, r := range rp.images[category] {
+			for _, img := range sprites.lumpImages(name) {
+				r.replace(img)
+			}
+		}
+	}
+}
+
+// lumpImages gets the pictures of a sprite lump name like TROOA1 or TROOA2A8.
+func (ss SpriteStore) lumpImages(name string) []*DoomPicture {
+	images := []*DoomPicture{}
+	if len(name) != 6 && len(name) != 8 {
+		return images
+	}
+	s, ok := ss[name[:4]]
+	if !ok {
+		return images
+	}
+	for i := 4; i+1 < len(name); i += 2 {
+		var (
+			sf, ok = s.frames[name[:4]+name[i:i+1]]
+			angle  = int(name[i+1] - '0')
+		)
+		if !ok || angle < 0 || angle >= len(sf.angles) {
+			continue
+		}
+		// both halves of a name usually share the picture
+		if pic, ok := sf.angles[angle].(*DoomPicture); ok && pic != nil &&
+			(len(images) == 0 || images[0] != pic) {
+			images = append(images, pic)
+		}
+	}
+	return images
+}
+
+func (rp *ReplacementPack) replace(category replacementCategory, name string, img HiResImage) {
+	if r, ok := rp.lookup(category, name); ok {
+		r.replace(img)
+	}
+}
+
+// replace sets the image of the file as replacement, files that can't be decoded are skipped.
+func (r replacement) replace(img HiResImage) {
+	rgba, err := r.decode()
+	if err != nil {
+		utils.GoomConsole.Print("skipping replacement: %s", err.Error())
+		return
+	}
+	img.SetHiRes(rgba)
+}
+
+func (r replacement) decode() (*image.RGBA, error) {
+	f, err := r.open()
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s: %s", r.file, err.Error())
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode %s: %s", r.file, err.Error())
+	}
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba, nil
+	}
+	rgba := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba, nil
+}
@@ -0,0 +1,103 @@
+package graphics
+
+import (
+	"archive/zip"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tinogoehlert/goom/test"
+)
+
+func writePNG(w io.Writer, width, height int, t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	test.Check(png.Encode(w, img), t)
+}
+
+// testStores has the texture WALL 64x128, the flat FLOOR and
+// the sprite TROO with frame A, whose angles 2 and 8 share a picture.
+func testStores() (TextureStore, FlatStore, SpriteStore) {
+	var (
+		textures = TextureStore{"WALL": &Texture{name: "WALL", width: 64, height: 128}}
+		flats    = FlatStore{"FLOOR": {&Flat{DoomPicture: newDummyPicture(64, 64), name: "FLOOR"}}}
+		sprites  = SpriteStore{"TROO": NewSprite("TROO")}
+		pic      = newDummyPicture(40, 50)
+	)
+	sprites["TROO"].frames["TROOA"] = &SpriteFrame{frame: 'A', name: "TROOA"}
+	sprites["TROO"].frames["TROOA"].angles[2] = pic
+	sprites["TROO"].frames["TROOA"].angles[8] = pic
+	return textures, flats, sprites
+}
+
+func TestReplacementPackDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hires")
+	test.Check(err, t)
+	defer os.RemoveAll(dir)
+	for _, f := range []struct {
+		file          string
+		width, height int
+	}{
+		{"textures/wall.png", 256, 512},
+		{"hires/FLOOR.png", 256, 256},
+		{"sprites/TROOA2A8.png", 160, 200},
+		{"sprites/readme.txt", 0, 0},
+		// broken images are skipped
+		{"textures/BROKEN.png", 0, 0},
+	} {
+		test.Check(os.MkdirAll(filepath.Join(dir, filepath.Dir(f.file)), 0755), t)
+		out, err := os.Create(filepath.Join(dir, f.file))
+		test.Check(err, t)
+		if f.width > 0 {
+			writePNG(out, f.width, f.height, t)
+		}
+		out.Close()
+	}
+
+	rp, err := LoadReplacementPack(dir)
+	test.Check(err, t)
+	textures, flats, sprites := testStores()
+	textures["BROKEN"] = &Texture{name: "BROKEN", width: 64, height: 64}
+	rp.Apply(textures, flats, sprites)
+
+	test.Assert(textures["BROKEN"].HiRes() == nil, "broken image must keep the original", t)
+	wall := textures["WALL"]
+	test.Assert(wall.HiRes() != nil && wall.HiRes().Bounds().Dx() == 256, "expected texture replacement", t)
+	test.Assert(wall.Width() == 64 && wall.Height() == 128, "texture must keep its original size", t)
+	test.Assert(TrueColor(wall, defaultPalette.Colors) == wall.HiRes(), "expected true color of the replacement", t)
+
+	floor := flats["FLOOR"][0]
+	test.Assert(floor.HiRes() != nil && floor.Width() == 64, "expected flat replacement from the hires directory", t)
+
+	troo := sprites["TROO"].frames["TROOA"].angles[8].(*DoomPicture)
+	test.Assert(troo.HiRes() != nil && troo.Width() == 40, "expected sprite replacement", t)
+}
+
+func TestReplacementPackArchive(t *testing.T) {
+	f, err := ioutil.TempFile("", "hires*.pk3")
+	test.Check(err, t)
+	defer os.Remove(f.Name())
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("flats/FLOOR.png")
+	test.Check(err, t)
+	writePNG(w, 128, 128, t)
+	w, err = zw.Create("textures/FLOOR.png")
+	test.Check(err, t)
+	writePNG(w, 32, 32, t)
+	test.Check(zw.Close(), t)
+	f.Close()
+
+	rp, err := LoadReplacementPack(f.Name())
+	test.Check(err, t)
+	defer rp.Close()
+	textures, flats, sprites := testStores()
+	rp.Apply(textures, flats, sprites)
+	floor := flats["FLOOR"][0]
+	test.Assert(floor.HiRes() != nil && floor.HiRes().Bounds().Dx() == 128, "expected the flat of the flats directory", t)
+	test.Assert(textures["WALL"].HiRes() == nil, "expected no texture replacement", t)
+}
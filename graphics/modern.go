@@ -0,0 +1,140 @@
+package graphics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/bmp"
+)
+
+var (
+	pngMagic  = []byte("\x89PNG\r\n\x1a\n")
+	jpegMagic = []byte{0xFF, 0xD8, 0xFF}
+	bmpMagic  = []byte("BM")
+)
+
+// grAbChunk PNG chunk holding the picture offsets
+const grAbChunk = "grAb"
+
+// isModernImage checks if a lump is a PNG, JPEG or BMP image.
+func isModernImage(buff []byte) bool {
+	return bytes.HasPrefix(buff, pngMagic) ||
+		bytes.HasPrefix(buff, jpegMagic) ||
+		bytes.HasPrefix(buff, bmpMagic)
+}
+
+// newModernPicture decodes a PNG, JPEG or BMP image. The true colour image is kept
+// for ToRGBA and quantized to the palette for ToPaletted, the offsets of PNGs
+// are read from the grAb chunk.
+func newModernPicture(buff []byte, palette [256]color.RGBA) (*DoomPicture, error) {
+	var (
+		img    image.Image
+		err    error
+		reader = bytes.NewReader(buff)
+	)
+	switch {
+	case bytes.HasPrefix(buff, pngMagic):
+		img, err = png.Decode(reader)
+	case bytes.HasPrefix(buff, jpegMagic):
+		img, err = jpeg.Decode(reader)
+	default:
+		img, err = bmp.Decode(reader)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not decode image: %s", err.Error())
+	}
+
+	var (
+		bounds = img.Bounds()
+		rgba   = image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	)
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+
+	dp := &DoomPicture{
+		width:  bounds.Dx(),
+		height: bounds.Dy(),
+		data:   quantize(rgba, palette),
+		rgba:   rgba,
+	}
+	if bytes.HasPrefix(buff, pngMagic) {
+		dp.left, dp.top = pngOffsets(buff)
+	}
+	return dp, nil
+}
+
+// pngOffsets reads the offsets of the grAb chunk, zero if the PNG has none.
+func pngOffsets(buff []byte) (left, top int) {
+	for i := len(pngMagic); i+8 <= len(buff); {
+		var (
+			length = int(binary.BigEndian.Uint32(buff[i : i+4]))
+			name   = string(buff[i+4 : i+8])
+			data   = i + 8
+		)
+		if length < 0 || data+length > len(buff) {
+			return 0, 0
+		}
+		switch name {
+		case grAbChunk:
+			if length < 8 {
+				return 0, 0
+			}
+			return int(int32(binary.BigEndian.Uint32(buff[data : data+4]))),
+				int(int32(binary.BigEndian.Uint32(buff[data+4 : data+8])))
+		case "IDAT", "IEND":
+			// grAb must come before the image data
+			return 0, 0
+		}
+		// skip data and CRC
+		i = data + length + 4
+	}
+	return 0, 0
+}
+
+// quantize maps an image to the nearest palette indices,
+// transparent pixels get the index 255 which is left out of the search.
+func quantize(img *image.RGBA, palette [256]color.RGBA) []uint8 {
+	var (
+		data  = make([]uint8, img.Rect.Dx()*img.Rect.Dy())
+		cache = make(map[color.RGBA]uint8)
+	)
+	for i := range data {
+		c := color.RGBA{img.Pix[i*4], img.Pix[i*4+1], img.Pix[i*4+2], 255}
+		if img.Pix[i*4+3] < 128 {
+			data[i] = transparentColor
+			continue
+		}
+		index, ok := cache[c]
+		if !ok {
+			index = nearestColor(c, palette)
+			cache[c] = index
+		}
+		data[i] = index
+	}
+	return data
+}
+
+func nearestColor(c color.RGBA, palette [256]color.RGBA) uint8 {
+	var (
+		best     = 0
+		bestDist = -1
+	)
+	for i := 0; i < transparentColor; i++ {
+		var (
+			p    = palette[i]
+			dr   = int(c.R) - int(p.R)
+			dg   = int(c.G) - int(p.G)
+			db   = int(c.B) - int(p.B)
+			dist = dr*dr + dg*dg + db*db
+		)
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return uint8(best)
+}
@@ -0,0 +1,69 @@
+package graphics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/tinogoehlert/goom/test"
+)
+
+func testPalette() [256]color.RGBA {
+	var palette [256]color.RGBA
+	for i := range palette {
+		palette[i] = color.RGBA{uint8(i), uint8(i), uint8(i), 255}
+	}
+	return palette
+}
+
+// withGrAb inserts a grAb chunk after the IHDR chunk of a PNG
+func withGrAb(buff []byte, left, top int32) []byte {
+	chunk := make([]byte, 8+8+4)
+	binary.BigEndian.PutUint32(chunk[0:], 8)
+	copy(chunk[4:], grAbChunk)
+	binary.BigEndian.PutUint32(chunk[8:], uint32(left))
+	binary.BigEndian.PutUint32(chunk[12:], uint32(top))
+	binary.BigEndian.PutUint32(chunk[16:], crc32.ChecksumIEEE(chunk[4:16]))
+
+	ihdrEnd := len(pngMagic) + 8 + 13 + 4
+	out := append([]byte{}, buff[:ihdrEnd]...)
+	out = append(out, chunk...)
+	return append(out, buff[ihdrEnd:]...)
+}
+
+func TestPNGPicture(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.NRGBA{100, 100, 100, 255})
+	img.Set(1, 0, color.NRGBA{0, 0, 0, 0})
+	var buff bytes.Buffer
+	test.Check(png.Encode(&buff, img), t)
+
+	dp, err := newModernPicture(withGrAb(buff.Bytes(), 5, -7), testPalette())
+	test.Check(err, t)
+	if dp == nil {
+		t.FailNow()
+	}
+	test.Assert(dp.Width() == 2 && dp.Height() == 1, "wrong size", t)
+	test.Assert(dp.Left() == 5 && dp.Top() == -7, "wrong grAb offsets", t)
+	test.Assert(dp.data[0] == 100, "expected nearest palette index", t)
+	test.Assert(dp.data[1] == transparentColor, "expected transparent pixel", t)
+	test.Assert(dp.ToRGBA(testPalette()).RGBAAt(0, 0).R == 100, "expected true colour", t)
+
+	dp.ToRGBA(testPalette()).Pix[0] = 7
+	test.Assert(dp.ToRGBA(testPalette()).RGBAAt(0, 0).R == 100, "writes must not change the decoded image", t)
+}
+
+func TestInvalidDoomPicture(t *testing.T) {
+	_, err := NewPicture([]byte{4, 0, 4, 0, 0, 0, 0, 0})
+	test.Assert(err != nil, "expected error for truncated column offsets", t)
+
+	buff := make([]byte, 12)
+	copy(buff, []byte{1, 0, 1, 0, 0, 0, 0, 0})
+	binary.LittleEndian.PutUint32(buff[8:], 200)
+	_, err = NewPicture(buff)
+	test.Assert(err != nil, "expected error for column out of range", t)
+}
@@ -0,0 +1,166 @@
+package graphics
+
+import (
+	"image/color"
+	"math"
+)
+
+// PLAYPAL palettes used for the effects, taken from vanilla
+const (
+	startRedPalettes   = 1
+	numRedPalettes     = 8
+	startBonusPalettes = 9
+	numBonusPalettes   = 4
+	radiationPalette   = 13
+
+	// NumGammaLevels gamma correction levels, 0 is no correction
+	NumGammaLevels = 5
+
+	// BonusAdd bonus count added for each pickup
+	BonusAdd = 6
+	// MaxDamageCount limit of the damage count
+	MaxDamageCount = 100
+	// RadiationSuitTics duration of the radiation suit
+	RadiationSuitTics = 60 * TicRate
+	// berserkFadeShift the berserk red fades out within 12 << 6 tics
+	berserkFadeShift = 6
+)
+
+// PaletteEffects are the player values selecting the palette,
+// all of them are counted in game tics.
+type PaletteEffects struct {
+	// DamageCount damage taken recently, decreases every tic
+	DamageCount int
+	// BonusCount recent pickups, decreases every tic
+	BonusCount int
+	// Berserk tics since a berserk pack was taken, 0 without one
+	Berserk int
+	// RadiationSuit tics left of the radiation suit
+	RadiationSuit int
+}
+
+// PaletteIndex gets the PLAYPAL palette to show, like vanilla's ST_doPaletteStuff.
+func (pe PaletteEffects) PaletteIndex() int {
+	count := pe.DamageCount
+	if pe.Berserk > 0 {
+		if bzc := 12 - (pe.Berserk >> berserkFadeShift); bzc > count {
+			count = bzc
+		}
+	}
+	switch {
+	case count > 0:
+		return startRedPalettes + clampPalette((count+7)>>3, numRedPalettes)
+	case pe.BonusCount > 0:
+		return startBonusPalettes + clampPalette((pe.BonusCount+7)>>3, numBonusPalettes)
+	case pe.RadiationSuit > 4*32 || pe.RadiationSuit&8 != 0:
+		// the suit blinks when running out
+		return radiationPalette
+	}
+	return 0
+}
+
+func clampPalette(p, n int) int {
+	if p >= n {
+		return n - 1
+	}
+	return p
+}
+
+// Get gets the palette with the index, falling back to the default palette.
+func (p *Palettes) Get(index int) Palette {
+	if index < 0 || index >= numPalettes {
+		index = 0
+	}
+	return p[index]
+}
+
+// GammaTable maps color intensities for the gamma correction level. The levels
+// follow the curves of the vanilla gamma table, from 1 down to an exponent of 0.5.
+func GammaTable(level int) [256]uint8 {
+	var (
+		table    [256]uint8
+		exponent = float64(GammaExponent(level))
+	)
+	for i := range table {
+		table[i] = uint8(math.Round(255 * math.Pow(float64(i)/255, exponent)))
+	}
+	return table
+}
+
+func clampGamma(level int) int {
+	if level < 0 {
+		return 0
+	}
+	if level >= NumGammaLevels {
+		return NumGammaLevels - 1
+	}
+	return level
+}
+
+// GammaExponent gets the exponent of the gamma correction level for shaders.
+func GammaExponent(level int) float32 {
+	return 1 - 0.125*float32(clampGamma(level))
+}
+
+// WithGamma gets the palette with gamma corrected colors.
+func (p Palette) WithGamma(level int) Palette {
+	if clampGamma(level) == 0 {
+		return p
+	}
+	table := GammaTable(level)
+	for i, c := range p.Colors {
+		p.Colors[i] = color.RGBA{table[c.R], table[c.G], table[c.B], c.A}
+	}
+	return p
+}
+
+// Tint gets the color the palette blends the base palette with, the alpha
+// is the strength of the blend. The effect palettes in PLAYPAL are the base
+// palette blended with a color, so true color renderers can draw the effect
+// as an overlay. The darkest and brightest colors of the base palette reveal
+// color and strength.
+func (p Palette) Tint(base Palette) color.RGBA {
+	dark, bright := 0, 0
+	for i, c := range base.Colors {
+		if luminance(c) < luminance(base.Colors[dark]) {
+			dark = i
+		}
+		if luminance(c) > luminance(base.Colors[bright]) {
+			bright = i
+		}
+	}
+	var (
+		b0, b1 = channels(base.Colors[dark]), channels(base.Colors[bright])
+		p0, p1 = channels(p.Colors[dark]), channels(p.Colors[bright])
+		alpha  float64
+		n      int
+	)
+	// p = b * (1 - alpha) + tint * alpha for both colors
+	for ch := range b0 {
+		if span := b1[ch] - b0[ch]; span > 0 {
+			alpha += 1 - (p1[ch]-p0[ch])/span
+			n++
+		}
+	}
+	if n > 0 {
+		alpha /= float64(n)
+	}
+	if alpha <= 0.001 {
+		return color.RGBA{}
+	}
+	alpha = math.Min(alpha, 1)
+	var tint [3]uint8
+	for ch := range tint {
+		v := (p0[ch] - b0[ch]*(1-alpha)) / alpha
+		tint[ch] = uint8(math.Max(0, math.Min(255, math.Round(v))))
+	}
+	return color.RGBA{tint[0], tint[1], tint[2], uint8(math.Round(alpha * 255))}
+}
+
+func luminance(c color.RGBA) int {
+	return int(c.R) + int(c.G) + int(c.B)
+}
+
+func channels(c color.RGBA) [3]float64 {
+	return [3]float64{float64(c.R), float64(c.G), float64(c.B)}
+}
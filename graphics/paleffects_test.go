@@ -0,0 +1,63 @@
+package graphics_test
+
+import (
+	"fmt"
+	"image/color"
+	"testing"
+
+	"github.com/tinogoehlert/goom/graphics"
+	"github.com/tinogoehlert/goom/test"
+)
+
+func TestPaletteIndex(t *testing.T) {
+	for _, c := range []struct {
+		effects graphics.PaletteEffects
+		index   int
+	}{
+		{graphics.PaletteEffects{}, 0},
+		{graphics.PaletteEffects{DamageCount: 1}, 2},
+		{graphics.PaletteEffects{DamageCount: 100}, 8},
+		{graphics.PaletteEffects{BonusCount: 6}, 10},
+		{graphics.PaletteEffects{BonusCount: 100}, 12},
+		{graphics.PaletteEffects{DamageCount: 10, BonusCount: 6}, 3},
+		{graphics.PaletteEffects{RadiationSuit: graphics.RadiationSuitTics}, 13},
+		{graphics.PaletteEffects{RadiationSuit: 7}, 0},
+		{graphics.PaletteEffects{RadiationSuit: 8}, 13},
+		{graphics.PaletteEffects{Berserk: 1}, 3},
+		{graphics.PaletteEffects{Berserk: 12 << 6}, 0},
+	} {
+		idx := c.effects.PaletteIndex()
+		test.Assert(idx == c.index, fmt.Sprintf("expected palette %d for %+v, got %d", c.index, c.effects, idx), t)
+	}
+}
+
+func TestGammaTable(t *testing.T) {
+	flat := graphics.GammaTable(0)
+	for i, v := range flat {
+		test.Assert(int(v) == i, "gamma level 0 must not change colors", t)
+	}
+	bright := graphics.GammaTable(4)
+	test.Assert(bright[0] == 0 && bright[255] == 255, "gamma must keep black and white", t)
+	test.Assert(bright[1] == 16, "expected vanilla's brightest gamma curve", t)
+	test.Assert(bright[128] > graphics.GammaTable(2)[128], "higher levels must be brighter", t)
+	test.Assert(graphics.GammaTable(9) == bright, "levels must be clamped", t)
+}
+
+func TestPaletteTint(t *testing.T) {
+	var base, red graphics.Palette
+	for i := range base.Colors {
+		v := uint8(i)
+		base.Colors[i] = color.RGBA{v, v / 2, v / 4, 255}
+		// a quarter red like the effect palettes
+		red.Colors[i] = color.RGBA{
+			uint8((int(v)*3 + 255) / 4),
+			uint8(int(v/2) * 3 / 4),
+			uint8(int(v/4) * 3 / 4),
+			255,
+		}
+	}
+	tint := red.Tint(base)
+	test.Assert(tint.R >= 250 && tint.G <= 5 && tint.B <= 5, "expected red tint", t)
+	test.Assert(tint.A >= 60 && tint.A <= 68, "expected a quarter blend", t)
+	test.Assert(base.Tint(base).A == 0, "the base palette must not tint", t)
+}
@@ -3,6 +3,7 @@ package graphics
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"image"
 	"image/color"
 	"image/png"
@@ -16,6 +17,7 @@ const (
 // Image generic Image
 type Image interface {
 	ToRGBA(palette [256]color.RGBA) *image.RGBA
+	ToPaletted(palette [256]color.RGBA) *image.Paletted
 	ToPng(out string, palette [256]color.RGBA) error
 	Width() int
 	Height() int
@@ -30,21 +32,48 @@ type DoomPicture struct {
 	left   int
 	top    int
 	data   []uint8
+	// rgba holds the true colour of pictures decoded from modern image formats
+	rgba *image.RGBA
+	hiRes
 }
 
-// NewDoomPicture gets picture from buffer
+// NewDoomPicture gets picture from buffer, nil is returned if the lump can't be decoded.
+// Besides the Doom picture format PNG, JPEG and BMP images are detected, see NewPicture.
 func NewDoomPicture(buff []byte) *DoomPicture {
+	dp, err := NewPicture(buff)
+	if err != nil {
+		return nil
+	}
+	return dp
+}
+
+// NewPicture decodes a lump in Doom picture format or as PNG, JPEG or BMP image.
+func NewPicture(buff []byte) (*DoomPicture, error) {
+	if isModernImage(buff) {
+		return newModernPicture(buff, defaultPalette.Colors)
+	}
+	return decodeDoomPicture(buff)
+}
+
+// decodeDoomPicture decodes the column based Doom picture format.
+func decodeDoomPicture(buff []byte) (*DoomPicture, error) {
+	if len(buff) < 8 {
+		return nil, fmt.Errorf("could not decode picture: header too short")
+	}
 	dp := &DoomPicture{
 		width:  int(int16(binary.LittleEndian.Uint16(buff[0:2]))),
 		height: int(int16(binary.LittleEndian.Uint16(buff[2:4]))),
 		left:   int(int16(binary.LittleEndian.Uint16(buff[4:6]))),
 		top:    int(int16(binary.LittleEndian.Uint16(buff[6:8]))),
 	}
+	if dp.width <= 0 || dp.height <= 0 || len(buff) < 8+dp.width*4 {
+		return nil, fmt.Errorf("could not decode picture: invalid size %dx%d", dp.width, dp.height)
+	}
 	offsets := make([]int32, dp.width, dp.width)
 	r := bytes.NewBuffer(buff[8 : 8+(dp.width*4)])
 
 	if err := binary.Read(r, binary.LittleEndian, offsets); err != nil {
-		return nil
+		return nil, fmt.Errorf("could not decode picture: %s", err.Error())
 	}
 
 	size := int(dp.width) * int(dp.height)
@@ -54,25 +83,38 @@ func NewDoomPicture(buff []byte) *DoomPicture {
 			dp.data[y*int(dp.width)+x] = transparentColor
 		}
 	}
-	for columnIndex, offset := range offsets {
+	errColumn := fmt.Errorf("could not decode picture: column out of range")
+	for columnIndex, o := range offsets {
+		offset := int(o)
 		for {
+			if offset < 0 || offset >= len(buff) {
+				return nil, errColumn
+			}
 			rowStart := buff[offset]
 			offset++
 			if rowStart == 255 {
 				break
 			}
+			if offset+2 > len(buff) {
+				return nil, errColumn
+			}
 			numPixels := buff[offset]
 			offset++
 			offset++ /* Padding */
+			if offset+int(numPixels) > len(buff) {
+				return nil, errColumn
+			}
 			for i := 0; i < int(numPixels); i++ {
-				pixelOffset := (int(rowStart)+i)*int(dp.width) + columnIndex
-				dp.data[pixelOffset] = buff[offset]
+				row := int(rowStart) + i
+				if row < dp.height {
+					dp.data[row*int(dp.width)+columnIndex] = buff[offset]
+				}
 				offset++
 			}
 			offset++ /* Padding */
 		}
 	}
-	return dp
+	return dp, nil
 }
 
 func newDummyPicture(width, height int) *DoomPicture {
@@ -95,8 +137,14 @@ func (p *DoomPicture) Top() int { return int(p.top) }
 // Left offset. The number of pixels to the left of the center; where the first column gets drawn.
 func (p *DoomPicture) Left() int { return int(p.left) }
 
-// ToRGBA converts picture to go image
+// ToRGBA converts picture to go image, every call gets an image of its own.
 func (p *DoomPicture) ToRGBA(palette [256]color.RGBA) *image.RGBA {
+	if p.rgba != nil {
+		// callers tint and write into the image, the decoded one stays untouched
+		tex := image.NewRGBA(p.rgba.Rect)
+		copy(tex.Pix, p.rgba.Pix)
+		return tex
+	}
 	bounds := image.Rect(0, 0, p.width, p.height)
 	tex := image.NewRGBA(bounds)
 	for y := 0; y < p.height; y++ {
@@ -112,9 +160,26 @@ func (p *DoomPicture) ToRGBA(palette [256]color.RGBA) *image.RGBA {
 	return tex
 }
 
+// ToPaletted converts picture to a go image keeping the palette indices.
+// Transparent pixels have the index 255.
+func (p *DoomPicture) ToPaletted(palette [256]color.RGBA) *image.Paletted {
+	tex := image.NewPaletted(image.Rect(0, 0, p.width, p.height), colorPalette(palette))
+	copy(tex.Pix, p.data)
+	return tex
+}
+
 // ToPng exports picture to PNG
 func (p *DoomPicture) ToPng(out string, palette [256]color.RGBA) error {
 	img := p.ToRGBA(palette)
 	f, _ := os.Create(out)
 	return png.Encode(f, img)
 }
+
+// colorPalette converts a DOOM palette to a go palette
+func colorPalette(palette [256]color.RGBA) color.Palette {
+	cp := make(color.Palette, len(palette))
+	for i, c := range palette {
+		cp[i] = c
+	}
+	return cp
+}
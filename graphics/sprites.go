@@ -6,24 +6,70 @@ import (
 	"github.com/tinogoehlert/goom/wad"
 )
 
+// NumRotations number of directions a sprite frame can be seen from
+const NumRotations = 8
+
 // SpriteFrames map of frames
 type SpriteFrames map[byte]SpriteFrame
 
-// SpriteFrame DOOM sprite frame
+// SpriteFrame DOOM sprite frame, angle 0 holds the picture of a frame
+// without rotations and angles 1-8 the pictures of the eight rotations.
 type SpriteFrame struct {
-	angles [9]Image
-	frame  byte
-	name   string
+	angles  [NumRotations + 1]Image
+	flipped [NumRotations + 1]bool
+	frame   byte
+	name    string
+}
+
+// SpriteView is the picture of a sprite frame seen from one rotation.
+type SpriteView struct {
+	Image Image
+	// Name and Rotation the picture is stored at in the frame
+	Name     string
+	Rotation int
+	// Flip the picture must be mirrored horizontally
+	Flip bool
 }
 
-func (sf *SpriteFrame) Image(angle int) Image {
-	if sf.angles[angle] != nil && angle == 1 {
-		return sf.angles[0]
+// Rotated checks if the frame has pictures for rotations.
+func (sf *SpriteFrame) Rotated() bool {
+	for _, img := range sf.angles[1:] {
+		if img != nil {
+			return true
+		}
 	}
-	return sf.angles[angle]
+	return false
 }
 
-func (sf *SpriteFrame) Angles() [9]Image {
+// View gets the picture for a rotation 1-8. Frames without rotations, or
+// missing a rotation, use the picture of rotation 0.
+func (sf *SpriteFrame) View(rotation int) (SpriteView, bool) {
+	if rotation < 1 || rotation > NumRotations {
+		rotation = 1
+	}
+	if sf.angles[rotation] == nil {
+		rotation = 0
+	}
+	if sf.angles[rotation] == nil {
+		return SpriteView{}, false
+	}
+	return SpriteView{
+		Image:    sf.angles[rotation],
+		Name:     sf.name,
+		Rotation: rotation,
+		Flip:     sf.flipped[rotation],
+	}, true
+}
+
+// Image gets the picture for a rotation and whether it is mirrored.
+func (sf *SpriteFrame) Image(rotation int) (Image, bool) {
+	v, _ := sf.View(rotation)
+	return v.Image, v.Flip
+}
+
+// Angles gets the pictures of rotation 0 and the rotations 1-8, mirrored
+// rotations share the picture of the rotation they mirror.
+func (sf *SpriteFrame) Angles() [NumRotations + 1]Image {
 	return sf.angles
 }
 
@@ -35,6 +81,17 @@ func (sf *SpriteFrame) Name() string {
 	return sf.name
 }
 
+// setRotation sets the picture of a rotation. Rotation 0 replaces all rotations,
+// so a PWAD can replace a rotated frame with a single picture.
+func (sf *SpriteFrame) setRotation(rotation int, img Image, flipped bool) {
+	if rotation == 0 {
+		sf.angles = [NumRotations + 1]Image{}
+		sf.flipped = [NumRotations + 1]bool{}
+	}
+	sf.angles[rotation] = img
+	sf.flipped[rotation] = flipped
+}
+
 // Sprite DOOM sprite
 type Sprite struct {
 	Name   string
@@ -50,33 +107,57 @@ func NewSprite(name string) Sprite {
 	}
 }
 
-// AddSpriteFrame Creates new sprite from lump
+// AddSpriteFrame adds the picture of a lump like TROOA1, or TROOA2A8 whose
+// picture is also the mirrored rotation of a second frame.
+// Malformed pictures are skipped, a nil picture must not become a non-nil Image.
 func (s *Sprite) AddSpriteFrame(lump *wad.Lump) *SpriteFrame {
-	var (
-		frame = lump.Name[4]
-	)
+	pic := NewDoomPicture(lump.Data)
+	if pic == nil {
+		return nil
+	}
+	return s.addLump(lump.Name, pic)
+}
 
-	sf, ok := s.frames[lump.Name[:5]]
-	if !ok {
-		sf = &SpriteFrame{
-			angles: [9]Image{},
-			frame:  frame,
-			name:   lump.Name[:5],
+func (s *Sprite) addLump(name string, img Image) *SpriteFrame {
+	if len(name) < 6 {
+		return nil
+	}
+	sf := s.frame(name[4])
+	if r := int(name[5] - '0'); r >= 0 && r <= NumRotations {
+		sf.setRotation(r, img, false)
+	}
+	if len(name) >= 8 {
+		if r := int(name[7] - '0'); r >= 0 && r <= NumRotations {
+			s.frame(name[6]).setRotation(r, img, true)
 		}
-		s.frames[lump.Name[:5]] = sf
 	}
-	sf.angles[lump.Name[5]-48] = NewDoomPicture(lump.Data)
-	if len(lump.Name) == 8 {
-		sf.angles[lump.Name[7]-48] = sf.angles[lump.Name[5]-48]
+	return sf
+}
+
+// frame gets a frame, creating it if missing.
+func (s *Sprite) frame(frame byte) *SpriteFrame {
+	name := s.Name + string(frame)
+	sf, ok := s.frames[name]
+	if !ok {
+		sf = &SpriteFrame{frame: frame, name: name}
+		s.frames[name] = sf
 	}
 	return sf
 }
 
-func (s *Sprite) GetFrame(angle, frame byte) *SpriteFrame {
-	if frame, ok := s.frames[s.Name+string(angle)+string(frame)]; !ok && frame != nil {
-		return frame
+// GetFrame gets a frame by its letter.
+func (s *Sprite) GetFrame(frame byte) (*SpriteFrame, bool) {
+	sf, ok := s.frames[s.Name+string(frame)]
+	return sf, ok
+}
+
+// View gets the picture of a frame seen from a rotation 1-8 and whether it is mirrored.
+func (s *Sprite) View(frame byte, rotation int) (SpriteView, bool) {
+	sf, ok := s.GetFrame(frame)
+	if !ok {
+		return SpriteView{}, false
 	}
-	return s.frames[s.first]
+	return sf.View(rotation)
 }
 
 func (s *Sprite) FirstFrame() *SpriteFrame {
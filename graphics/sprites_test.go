@@ -0,0 +1,76 @@
+package graphics
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tinogoehlert/goom/test"
+	"github.com/tinogoehlert/goom/wad"
+)
+
+func TestSpriteRotations(t *testing.T) {
+	var (
+		s       = NewSprite("TROO")
+		front   = newDummyPicture(10, 10)
+		left    = newDummyPicture(10, 10)
+		back    = newDummyPicture(10, 10)
+		dead    = newDummyPicture(10, 10)
+		shared  = newDummyPicture(10, 10)
+		pwadTwo = newDummyPicture(10, 10)
+	)
+	s.addLump("TROOA1", front)
+	s.addLump("TROOA2A8", left)
+	s.addLump("TROOA5", back)
+	s.addLump("TROOB3C7", shared)
+	s.addLump("TROOM0", dead)
+
+	for _, c := range []struct {
+		frame    byte
+		rotation int
+		img      Image
+		flip     bool
+	}{
+		{'A', 1, front, false},
+		{'A', 2, left, false},
+		{'A', 8, left, true},
+		{'A', 5, back, false},
+		{'A', 0, front, false},
+		{'B', 3, shared, false},
+		{'C', 7, shared, true},
+		{'M', 1, dead, false},
+		{'M', 6, dead, false},
+	} {
+		v, ok := s.View(c.frame, c.rotation)
+		test.Assert(ok, fmt.Sprintf("expected frame %c", c.frame), t)
+		test.Assert(v.Image == c.img && v.Flip == c.flip,
+			fmt.Sprintf("unexpected picture of frame %c rotation %d", c.frame, c.rotation), t)
+	}
+	_, ok := s.View('Z', 1)
+	test.Assert(!ok, "missing frames must not fall back to the first frame", t)
+
+	sf, _ := s.GetFrame('A')
+	test.Assert(sf.Rotated(), "expected a rotated frame", t)
+
+	// a PWAD replaces single rotations and mirrored rotations
+	s.addLump("TROOA8", pwadTwo)
+	v, _ := s.View('A', 8)
+	test.Assert(v.Image == pwadTwo && !v.Flip, "expected the replaced rotation", t)
+	v, _ = s.View('A', 2)
+	test.Assert(v.Image == left, "other rotations must be kept", t)
+
+	// a single picture replaces all rotations
+	s.addLump("TROOA0", dead)
+	sf, _ = s.GetFrame('A')
+	v, _ = s.View('A', 2)
+	test.Assert(!sf.Rotated() && v.Image == dead && v.Rotation == 0, "expected the rotation-less picture", t)
+}
+
+func TestMalformedSpriteFrame(t *testing.T) {
+	s := NewSprite("TROO")
+	sf := s.AddSpriteFrame(&wad.Lump{Name: "TROOA1", Data: []byte{1, 2, 3}})
+	test.Assert(sf == nil, "expected the malformed frame skipped", t)
+	_, ok := s.GetFrame('A')
+	test.Assert(!ok, "a malformed frame must not be added", t)
+	_, ok = s.View('A', 1)
+	test.Assert(!ok, "a malformed frame must have no view", t)
+}
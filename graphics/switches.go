@@ -0,0 +1,140 @@
+package graphics
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/tinogoehlert/goom/wad"
+)
+
+const switchRecordSize = 20
+
+// SwitchDef pairs the off and on texture of a switch.
+// Episode is 1 for shareware, 2 for registered and 3 for commercial textures.
+type SwitchDef struct {
+	Off     string
+	On      string
+	Episode int
+}
+
+// vanillaSwitches the switch textures hardcoded in vanilla, used without SWITCHES lump
+var vanillaSwitches = []SwitchDef{
+	{"SW1BRCOM", "SW2BRCOM", 1},
+	{"SW1BRN1", "SW2BRN1", 1},
+	{"SW1BRN2", "SW2BRN2", 1},
+	{"SW1BRNGN", "SW2BRNGN", 1},
+	{"SW1BROWN", "SW2BROWN", 1},
+	{"SW1COMM", "SW2COMM", 1},
+	{"SW1COMP", "SW2COMP", 1},
+	{"SW1DIRT", "SW2DIRT", 1},
+	{"SW1EXIT", "SW2EXIT", 1},
+	{"SW1GRAY", "SW2GRAY", 1},
+	{"SW1GRAY1", "SW2GRAY1", 1},
+	{"SW1METAL", "SW2METAL", 1},
+	{"SW1PIPE", "SW2PIPE", 1},
+	{"SW1SLAD", "SW2SLAD", 1},
+	{"SW1STARG", "SW2STARG", 1},
+	{"SW1STON1", "SW2STON1", 1},
+	{"SW1STON2", "SW2STON2", 1},
+	{"SW1STONE", "SW2STONE", 1},
+	{"SW1STRTN", "SW2STRTN", 1},
+
+	{"SW1BLUE", "SW2BLUE", 2},
+	{"SW1CMT", "SW2CMT", 2},
+	{"SW1GARG", "SW2GARG", 2},
+	{"SW1GSTON", "SW2GSTON", 2},
+	{"SW1HOT", "SW2HOT", 2},
+	{"SW1LION", "SW2LION", 2},
+	{"SW1SATYR", "SW2SATYR", 2},
+	{"SW1SKIN", "SW2SKIN", 2},
+	{"SW1VINE", "SW2VINE", 2},
+	{"SW1WOOD", "SW2WOOD", 2},
+
+	{"SW1PANEL", "SW2PANEL", 3},
+	{"SW1ROCK", "SW2ROCK", 3},
+	{"SW1MET2", "SW2MET2", 3},
+	{"SW1WDMET", "SW2WDMET", 3},
+	{"SW1BRIK", "SW2BRIK", 3},
+	{"SW1MOD1", "SW2MOD1", 3},
+	{"SW1ZIM", "SW2ZIM", 3},
+	{"SW1STON6", "SW2STON6", 3},
+	{"SW1TEK", "SW2TEK", 3},
+	{"SW1MARB", "SW2MARB", 3},
+	{"SW1SKULL", "SW2SKULL", 3},
+}
+
+// Switches table of switch texture pairs.
+type Switches struct {
+	defs  []SwitchDef
+	pairs map[string]string
+}
+
+// NewSwitches creates a table with the vanilla switches.
+func NewSwitches() *Switches {
+	return &Switches{
+		defs:  append([]SwitchDef{}, vanillaSwitches...),
+		pairs: make(map[string]string),
+	}
+}
+
+// LoadWAD reads the Boom SWITCHES lump, which replaces the vanilla switches.
+// Call InitPairs once all WADs are loaded.
+func (s *Switches) LoadWAD(w *wad.WAD) error {
+	lump := w.Lump("SWITCHES")
+	if lump == nil {
+		return nil
+	}
+	defs, err := parseSwitches(lump)
+	if err != nil {
+		return err
+	}
+	s.defs = defs
+	return nil
+}
+
+// InitPairs enables the switches whose textures both exist, which leaves
+// out the textures of episodes missing in the loaded WADs.
+func (s *Switches) InitPairs(textures TextureStore) {
+	s.pairs = make(map[string]string)
+	for _, def := range s.defs {
+		if textures[def.Off] == nil || textures[def.On] == nil {
+			continue
+		}
+		s.pairs[def.Off] = def.On
+		s.pairs[def.On] = def.Off
+	}
+}
+
+// Toggle gets the other texture of the switch pair of name.
+// ok is false if name is no switch texture.
+func (s *Switches) Toggle(name string) (other string, ok bool) {
+	if s == nil {
+		return name, false
+	}
+	other, ok = s.pairs[name]
+	if !ok {
+		return name, false
+	}
+	return other, true
+}
+
+// parseSwitches reads the records of a SWITCHES lump.
+func parseSwitches(lump *wad.Lump) ([]SwitchDef, error) {
+	defs := []SwitchDef{}
+	for i := 0; ; i += switchRecordSize {
+		if i+switchRecordSize > len(lump.Data) {
+			return nil, fmt.Errorf("SWITCHES: missing end marker")
+		}
+		rec := lump.Data[i : i+switchRecordSize]
+		episode := int(int16(binary.LittleEndian.Uint16(rec[18:20])))
+		if episode == 0 {
+			return defs, nil
+		}
+		defs = append(defs, SwitchDef{
+			Off:     strings.ToUpper(cString(rec[0:9])),
+			On:      strings.ToUpper(cString(rec[9:18])),
+			Episode: episode,
+		})
+	}
+}
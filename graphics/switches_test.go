@@ -0,0 +1,41 @@
+package graphics
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/tinogoehlert/goom/test"
+	"github.com/tinogoehlert/goom/wad"
+)
+
+func switchRecord(off, on string, episode int16) []byte {
+	rec := make([]byte, switchRecordSize)
+	copy(rec[0:9], off)
+	copy(rec[9:18], on)
+	binary.LittleEndian.PutUint16(rec[18:], uint16(episode))
+	return rec
+}
+
+func TestSwitchPairs(t *testing.T) {
+	s := NewSwitches()
+	s.InitPairs(TextureStore{"SW1BRCOM": &Texture{}, "SW2BRCOM": &Texture{}, "SW1BLUE": &Texture{}})
+
+	other, ok := s.Toggle("SW1BRCOM")
+	test.Assert(ok && other == "SW2BRCOM", "expected on texture", t)
+	other, ok = s.Toggle("SW2BRCOM")
+	test.Assert(ok && other == "SW1BRCOM", "expected off texture", t)
+	_, ok = s.Toggle("SW1BLUE")
+	test.Assert(!ok, "switch with missing texture must be disabled", t)
+	_, ok = s.Toggle("STARTAN3")
+	test.Assert(!ok, "STARTAN3 is no switch", t)
+}
+
+func TestSwitchesLump(t *testing.T) {
+	data := append(switchRecord("SW1MINE", "SW2MINE", 1), switchRecord("", "", 0)...)
+	defs, err := parseSwitches(&wad.Lump{Name: "SWITCHES", Size: len(data), Data: data})
+	test.Check(err, t)
+	test.Assert(len(defs) == 1 && defs[0].Off == "SW1MINE" && defs[0].On == "SW2MINE", "wrong switch definition", t)
+
+	_, err = parseSwitches(&wad.Lump{Name: "SWITCHES", Size: switchRecordSize, Data: data[:switchRecordSize]})
+	test.Assert(err != nil, "expected error for missing end marker", t)
+}
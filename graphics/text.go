@@ -0,0 +1,200 @@
+package graphics
+
+import (
+	"image"
+	"image/draw"
+	"strings"
+	"unicode"
+)
+
+// spaceWidth width of a space and of runes no font has a glyph for, like in vanilla
+const spaceWidth = 4
+
+// Align horizontal alignment of the lines of a text
+type Align int
+
+// text alignments
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// TextStyle describes how a text is laid out.
+type TextStyle struct {
+	// Fonts the first font having a glyph for a rune is used
+	Fonts []FontName
+	Align Align
+	// MaxWidth lines are wrapped at spaces to fit the width, 0 disables wrapping
+	MaxWidth int
+	// LineSpacing pixels between two lines
+	LineSpacing int
+	// Tracking pixels between two glyphs
+	Tracking int
+}
+
+// PlacedGlyph is a glyph at its position in a text layout.
+type PlacedGlyph struct {
+	*Glyph
+	X, Y int
+}
+
+// TextLayout is a text laid out in lines of glyphs. Positions are relative
+// to the top left corner of the text's box, which is MaxWidth wide if set.
+type TextLayout struct {
+	Glyphs []PlacedGlyph
+	Width  int
+	Height int
+	Lines  int
+}
+
+// GraphicDrawer draws named graphics, e.g. a renderer drawing on the 320x200 screen.
+type GraphicDrawer interface {
+	DrawGraphic(name string, x, y float32)
+}
+
+// Glyph gets the glyph of the rune from the first font of the style having it.
+// Lower case runes fall back to upper case, at last the first font's fallback rune is used.
+func (fb FontBook) Glyph(r rune, style TextStyle) *Glyph {
+	for _, c := range []rune{r, unicode.ToUpper(r)} {
+		for _, name := range style.Fonts {
+			if g, ok := fb[name].Glyph(c); ok {
+				return g
+			}
+		}
+	}
+	if len(style.Fonts) > 0 {
+		if g := fb[style.Fonts[0]].GetGlyph(r); g != nil && g.DoomPicture != nil {
+			return g
+		}
+	}
+	return nil
+}
+
+// lineHeight height of the tallest glyph of the style's fonts
+func (fb FontBook) lineHeight(style TextStyle) int {
+	h := 0
+	for _, name := range style.Fonts {
+		if fh := fb[name].Height(); fh > h {
+			h = fh
+		}
+	}
+	return h
+}
+
+// Measure gets the size of the text's layout.
+func (fb FontBook) Measure(text string, style TextStyle) (width, height int) {
+	tl := fb.Layout(text, style)
+	return tl.Width, tl.Height
+}
+
+// Layout lays out the text in lines. Lines are broken at new lines and,
+// with a MaxWidth, at the last space fitting into the line.
+func (fb FontBook) Layout(text string, style TextStyle) *TextLayout {
+	var (
+		tl         = &TextLayout{}
+		lineHeight = fb.lineHeight(style)
+		lines      = [][]PlacedGlyph{}
+		widths     = []int{}
+	)
+	for _, paragraph := range strings.Split(text, "\n") {
+		for _, line := range fb.wrap(paragraph, style) {
+			glyphs, w := fb.layoutLine(line, style)
+			lines = append(lines, glyphs)
+			widths = append(widths, w)
+			if w > tl.Width {
+				tl.Width = w
+			}
+		}
+	}
+
+	box := tl.Width
+	if style.MaxWidth > 0 {
+		box = style.MaxWidth
+	}
+	for i, glyphs := range lines {
+		var (
+			x = 0
+			y = i * (lineHeight + style.LineSpacing)
+		)
+		switch style.Align {
+		case AlignCenter:
+			x = (box - widths[i]) / 2
+		case AlignRight:
+			x = box - widths[i]
+		}
+		for _, pg := range glyphs {
+			pg.X += x
+			pg.Y = y
+			tl.Glyphs = append(tl.Glyphs, pg)
+		}
+	}
+	tl.Lines = len(lines)
+	if tl.Lines > 0 {
+		tl.Height = tl.Lines*lineHeight + (tl.Lines-1)*style.LineSpacing
+	}
+	return tl
+}
+
+// layoutLine places the glyphs of a line next to each other.
+func (fb FontBook) layoutLine(line string, style TextStyle) ([]PlacedGlyph, int) {
+	var (
+		glyphs = []PlacedGlyph{}
+		x      = 0
+	)
+	for i, r := range []rune(line) {
+		if i > 0 {
+			x += style.Tracking
+		}
+		g := fb.Glyph(r, style)
+		if r == ' ' || g == nil {
+			x += spaceWidth
+			continue
+		}
+		glyphs = append(glyphs, PlacedGlyph{Glyph: g, X: x})
+		x += g.Width()
+	}
+	return glyphs, x
+}
+
+// wrap breaks a line without new lines at spaces to fit into MaxWidth.
+// Words wider than MaxWidth get a line of their own.
+func (fb FontBook) wrap(line string, style TextStyle) []string {
+	if style.MaxWidth <= 0 {
+		return []string{line}
+	}
+	var (
+		lines   = []string{}
+		current = ""
+	)
+	for _, word := range strings.Split(line, " ") {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if _, w := fb.layoutLine(candidate, style); w <= style.MaxWidth || current == "" {
+			current = candidate
+			continue
+		}
+		lines = append(lines, current)
+		current = word
+	}
+	return append(lines, current)
+}
+
+// Draw draws the text with its top left corner at x, y.
+func (tl *TextLayout) Draw(d GraphicDrawer, x, y float32) {
+	for _, pg := range tl.Glyphs {
+		d.DrawGraphic(pg.GetName(), x+float32(pg.X), y+float32(pg.Y))
+	}
+}
+
+// DrawRGBA draws the text into an image with its top left corner at x, y.
+// Glyph offsets are applied the same way renderers apply them.
+func (tl *TextLayout) DrawRGBA(dst *image.RGBA, x, y int, palette Palette) {
+	for _, pg := range tl.Glyphs {
+		src := pg.ToRGBA(palette.Colors)
+		at := image.Pt(x+pg.X-pg.Left(), y+pg.Y-pg.Top())
+		draw.Draw(dst, src.Bounds().Add(at), src, image.Point{}, draw.Over)
+	}
+}
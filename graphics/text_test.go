@@ -0,0 +1,108 @@
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/tinogoehlert/goom/test"
+)
+
+// testFontBook has a font with the upper case letters A - C, each one
+// 5 pixels wider than the previous, and a number font with the digit 1.
+func testFontBook() FontBook {
+	fb := NewFontBook()
+	for i, r := range "ABC" {
+		fb[FnCompositeRed].addGlyph(Glyph{
+			DoomPicture: newDummyPicture(5*(i+1), 7),
+			name:        "STCFN" + string(r),
+			char:        r,
+		})
+	}
+	fb[FnNumRedBig].addGlyph(Glyph{DoomPicture: newDummyPicture(14, 16), name: "STTNUM1", char: '1'})
+	return fb
+}
+
+func TestMeasureUsesGlyphWidths(t *testing.T) {
+	var (
+		fb    = testFontBook()
+		style = TextStyle{Fonts: []FontName{FnCompositeRed}}
+	)
+	w, h := fb.Measure("AB C", style)
+	test.Assert(w == 5+10+spaceWidth+15, "unexpected width", t)
+	test.Assert(h == 7, "unexpected height", t)
+
+	w, _ = fb.Measure("abc", style)
+	test.Assert(w == 30, "lower case must fall back to upper case", t)
+
+	style.Tracking = 1
+	w, _ = fb.Measure("ABC", style)
+	test.Assert(w == 32, "expected tracking between glyphs", t)
+}
+
+func TestFontFallback(t *testing.T) {
+	var (
+		fb    = testFontBook()
+		style = TextStyle{Fonts: []FontName{FnCompositeRed, FnNumRedBig}}
+	)
+	g := fb.Glyph('1', style)
+	test.Assert(g != nil && g.GetName() == "STTNUM1", "expected glyph of the second font", t)
+	w, h := fb.Measure("A1", style)
+	test.Assert(w == 19 && h == 16, "expected mixed fonts to be measured", t)
+}
+
+func TestLayoutWrapAndAlign(t *testing.T) {
+	var (
+		fb    = testFontBook()
+		style = TextStyle{
+			Fonts:       []FontName{FnCompositeRed},
+			Align:       AlignRight,
+			MaxWidth:    35,
+			LineSpacing: 2,
+		}
+		tl = fb.Layout("CC A\nB", style)
+	)
+	// "CC A" is 39 pixels wide and gets wrapped
+	test.Assert(tl.Lines == 3, "expected three lines", t)
+	test.Assert(tl.Height == 3*7+2*2, "unexpected height", t)
+	test.Assert(len(tl.Glyphs) == 4, "expected four glyphs", t)
+	test.Assert(tl.Glyphs[0].X == 5 && tl.Glyphs[1].X == 20, "expected first line right aligned", t)
+	test.Assert(tl.Glyphs[2].X == 30 && tl.Glyphs[2].Y == 9, "expected second line below the first", t)
+	test.Assert(tl.Glyphs[3].GetName() == "STCFNB" && tl.Glyphs[3].X == 25 && tl.Glyphs[3].Y == 18, "expected third line", t)
+
+	style.Align = AlignCenter
+	tl = fb.Layout("A", style)
+	test.Assert(tl.Glyphs[0].X == 15, "expected centered glyph", t)
+}
+
+type drawnGraphic struct {
+	name string
+	x, y float32
+}
+
+type recorder []drawnGraphic
+
+func (r *recorder) DrawGraphic(name string, x, y float32) {
+	*r = append(*r, drawnGraphic{name, x, y})
+}
+
+func TestDrawText(t *testing.T) {
+	var (
+		fb = testFontBook()
+		tl = fb.Layout("AB", TextStyle{Fonts: []FontName{FnCompositeRed}})
+		r  = recorder{}
+	)
+	tl.Draw(&r, 100, 50)
+	test.Assert(len(r) == 2, "expected two graphics", t)
+	test.Assert(r[1] == drawnGraphic{"STCFNB", 105, 50}, "unexpected position", t)
+
+	var (
+		img     = image.NewRGBA(image.Rect(0, 0, 20, 10))
+		palette = Palette{}
+	)
+	palette.Colors[0] = color.RGBA{255, 0, 0, 255}
+	tl.DrawRGBA(img, 1, 1, palette)
+	test.Assert(img.RGBAAt(0, 0).A == 0, "expected empty border", t)
+	test.Assert(img.RGBAAt(1, 1).A == 255, "expected glyph pixel", t)
+	test.Assert(img.RGBAAt(15, 7).A == 255, "expected second glyph pixel", t)
+}
@@ -26,6 +26,7 @@ type Texture struct {
 	height     int
 	patchCount int
 	patches    []*Patch
+	hiRes
 }
 
 var pnameStore = []string{}
@@ -89,6 +90,30 @@ func (t *Texture) ToRGBA(palette [256]color.RGBA) *image.RGBA {
 	return img
 }
 
+// ToPaletted generates a go image of palette indices from all patches.
+// Transparent pixels have the index 255.
+func (t *Texture) ToPaletted(palette [256]color.RGBA) *image.Paletted {
+	img := image.NewPaletted(image.Rect(0, 0, t.width, t.height), colorPalette(palette))
+	for i := range img.Pix {
+		img.Pix[i] = transparentColor
+	}
+	for _, patch := range t.patches {
+		if patch.DoomPicture == nil {
+			continue
+		}
+		for y := 0; y < patch.height; y++ {
+			for x := 0; x < patch.width; x++ {
+				pixel := patch.data[y*patch.width+x]
+				if pixel == transparentColor {
+					continue
+				}
+				img.SetColorIndex(patch.originX+x, patch.originY+y, pixel)
+			}
+		}
+	}
+	return img
+}
+
 // ToPng exports picture to PNG
 func (t *Texture) ToPng(out string, palette [256]color.RGBA) error {
 	img := t.ToRGBA(palette)
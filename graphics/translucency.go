@@ -0,0 +1,112 @@
+package graphics
+
+import (
+	"fmt"
+
+	"github.com/tinogoehlert/goom/wad"
+)
+
+const (
+	// TranslucencyAlpha opacity of translucent sprites, the default of Boom's TRANMAP
+	TranslucencyAlpha = 0.66
+	// FuzzColormap map darkening the pixels behind fuzzy sprites, like vanilla's colormaps[6*256]
+	FuzzColormap = 6
+	// TranMapName name of Boom's lump with a prebuilt TranMap
+	TranMapName = "TRANMAP"
+)
+
+// fuzzOffsets rows above (-1) or below (1) vanilla's fuzz effect copies pixels from
+var fuzzOffsets = [...]int{
+	1, -1, 1, -1, 1, 1, -1,
+	1, 1, -1, 1, 1, 1, -1,
+	1, 1, 1, -1, -1, -1, -1,
+	1, -1, -1, 1, 1, 1, 1, -1,
+	1, -1, 1, 1, -1, -1, 1,
+	1, -1, -1, -1, -1, 1, 1,
+	1, 1, -1, 1, 1, -1, 1,
+}
+
+// FuzzOffsets gets the row offsets of the fuzz effect, for renderers walking them on their own.
+func FuzzOffsets() []int {
+	return append([]int(nil), fuzzOffsets[:]...)
+}
+
+// Fuzz walks through the row offsets of the fuzz effect, the position is kept
+// between frames so the shape of a partially invisible thing shimmers.
+type Fuzz struct {
+	pos int
+}
+
+// Next gets the row offset of the next fuzzy pixel.
+func (f *Fuzz) Next() int {
+	offset := fuzzOffsets[f.pos]
+	f.pos = (f.pos + 1) % len(fuzzOffsets)
+	return offset
+}
+
+// TranMap blends palette indices of a translucent foreground with the background,
+// indexed by foreground and background.
+type TranMap [256][256]uint8
+
+// NewTranMap builds the table blending the foreground with opacity alpha,
+// every blended color is mapped to the nearest color of the palette.
+func NewTranMap(p Palette, alpha float32) *TranMap {
+	var tm TranMap
+	for fg := range p.Colors {
+		for bg := range p.Colors {
+			var (
+				f, b = p.Colors[fg], p.Colors[bg]
+				r    = float32(f.R)*alpha + float32(b.R)*(1-alpha)
+				g    = float32(f.G)*alpha + float32(b.G)*(1-alpha)
+				bl   = float32(f.B)*alpha + float32(b.B)*(1-alpha)
+			)
+			tm[fg][bg] = p.nearest(r, g, bl)
+		}
+	}
+	return &tm
+}
+
+// LoadTranMap reads the TRANMAP lump, nil is returned if the WAD has none.
+func LoadTranMap(w *wad.WAD) (*TranMap, error) {
+	lump := w.Lump(TranMapName)
+	if lump == nil {
+		return nil, nil
+	}
+	return parseTranMap(lump)
+}
+
+func parseTranMap(lump *wad.Lump) (*TranMap, error) {
+	var tm TranMap
+	if len(lump.Data) < len(tm)*len(tm[0]) {
+		return nil, fmt.Errorf("%s: size missmatch", lump.Name)
+	}
+	for fg := range tm {
+		copy(tm[fg][:], lump.Data[fg*len(tm[0]):])
+	}
+	return &tm, nil
+}
+
+// Blend gets the palette index of fg drawn translucent over bg.
+func (tm *TranMap) Blend(fg, bg uint8) uint8 {
+	return tm[fg][bg]
+}
+
+// nearest gets the index of the palette color closest to r, g, b.
+func (p Palette) nearest(r, g, b float32) uint8 {
+	var (
+		best     uint8
+		bestDist float32 = -1
+	)
+	for i, c := range p.Colors {
+		var (
+			dr = float32(c.R) - r
+			dg = float32(c.G) - g
+			db = float32(c.B) - b
+			d  = dr*dr + dg*dg + db*db
+		)
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = uint8(i), d
+		}
+	}
+	return best
+}
@@ -0,0 +1,53 @@
+package graphics
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/tinogoehlert/goom/test"
+	"github.com/tinogoehlert/goom/wad"
+)
+
+func TestTranMap(t *testing.T) {
+	var p Palette
+	for i := range p.Colors {
+		p.Colors[i] = color.RGBA{R: uint8(i), G: uint8(i), B: uint8(i), A: 255}
+	}
+	tm := NewTranMap(p, 0.5)
+	test.Assert(tm.Blend(200, 200) == 200, "blending a color with itself must keep it", t)
+	test.Assert(tm.Blend(200, 0) == 100, "expected the color between foreground and background", t)
+
+	tm = NewTranMap(p, TranslucencyAlpha)
+	test.Assert(tm.Blend(255, 0) > 128, "the foreground must outweigh the background", t)
+}
+
+func TestTranMapLump(t *testing.T) {
+	data := make([]byte, 256*256)
+	data[7*256+9] = 11
+	tm, err := parseTranMap(&wad.Lump{Name: TranMapName, Size: len(data), Data: data})
+	test.Check(err, t)
+	test.Assert(tm.Blend(7, 9) == 11, "expected the blend of the lump, indexed by foreground and background", t)
+
+	_, err = parseTranMap(&wad.Lump{Name: TranMapName, Size: 256, Data: data[:256]})
+	test.Assert(err != nil, "expected error for a short lump", t)
+}
+
+func TestFuzz(t *testing.T) {
+	var (
+		f     Fuzz
+		first = make([]int, 0, 50)
+	)
+	for i := 0; i < 50; i++ {
+		o := f.Next()
+		test.Assert(o == 1 || o == -1, "fuzz must copy from the row above or below", t)
+		first = append(first, o)
+	}
+	for i := 0; i < 50; i++ {
+		test.Assert(f.Next() == first[i], "fuzz offsets must repeat", t)
+	}
+	offsets := FuzzOffsets()
+	test.Assert(len(offsets) == 50, "expected the 50 offsets of vanilla", t)
+	for i, o := range offsets {
+		test.Assert(o == first[i], "expected the offsets of the fuzz effect", t)
+	}
+}
@@ -0,0 +1,97 @@
+package hud
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/tinogoehlert/goom/game"
+	"github.com/tinogoehlert/goom/graphics"
+)
+
+const (
+	numPainFaces = 5
+	// muchPain health lost at once that makes the marine say ouch
+	muchPain = 20
+
+	straightFaceTics = graphics.TicRate / 2
+	turnFaceTics     = graphics.TicRate
+	evilGrinTics     = 2 * graphics.TicRate
+)
+
+// face priorities, a face is only replaced by one with the same or a higher priority
+// until its time is up
+const (
+	priorityStraight = iota
+	priorityGod      = 4
+	priorityPain     = 7
+	priorityEvilGrin = 8
+	priorityDead     = 9
+)
+
+// face is the animated marine face of the status bar.
+type face struct {
+	name       string
+	priority   int
+	count      int
+	oldHealth  int
+	oldWeapons int
+	rnd        *rand.Rand
+}
+
+func newFace(s game.Status) *face {
+	return &face{
+		oldHealth:  s.Health,
+		oldWeapons: s.Weapons,
+		rnd:        rand.New(rand.NewSource(1)),
+	}
+}
+
+// painLevel gets the pain faces to use for the health, 0 is healthy.
+func painLevel(health int) int {
+	if health > 100 {
+		health = 100
+	}
+	if health < 0 {
+		health = 0
+	}
+	return (100 - health) * numPainFaces / 101
+}
+
+// tick advances the face by one game tic.
+func (f *face) tick(s game.Status) {
+	pain := painLevel(s.Health)
+
+	if f.priority <= priorityDead && s.Health <= 0 {
+		f.set(priorityDead, 1, "STFDEAD0")
+	}
+
+	if f.priority <= priorityEvilGrin && s.Weapons > f.oldWeapons {
+		f.set(priorityEvilGrin, evilGrinTics, fmt.Sprintf("STFEVL%d", pain))
+	}
+
+	if f.priority <= priorityPain && s.Health > 0 && s.Health < f.oldHealth {
+		if f.oldHealth-s.Health > muchPain {
+			f.set(priorityPain, turnFaceTics, fmt.Sprintf("STFOUCH%d", pain))
+		} else {
+			f.set(priorityPain, turnFaceTics, fmt.Sprintf("STFKILL%d", pain))
+		}
+	}
+
+	if f.priority <= priorityGod && s.God {
+		f.set(priorityGod, 1, "STFGOD0")
+	}
+
+	if f.count <= 0 {
+		f.set(priorityStraight, straightFaceTics, fmt.Sprintf("STFST%d%d", pain, f.rnd.Intn(3)))
+	}
+	f.count--
+
+	f.oldHealth = s.Health
+	f.oldWeapons = s.Weapons
+}
+
+func (f *face) set(priority, count int, name string) {
+	f.priority = priority
+	f.count = count
+	f.name = name
+}
@@ -0,0 +1,76 @@
+package hud
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tinogoehlert/goom/game"
+	"github.com/tinogoehlert/goom/graphics"
+	"github.com/tinogoehlert/goom/test"
+)
+
+func TestPainLevel(t *testing.T) {
+	test.Assert(painLevel(100) == 0, "expected healthy face at 100%", t)
+	test.Assert(painLevel(200) == 0, "expected healthy face above 100%", t)
+	test.Assert(painLevel(1) == 4, "expected most pain at 1%", t)
+	test.Assert(painLevel(50) == 2, "expected medium pain at 50%", t)
+}
+
+func TestFaceStates(t *testing.T) {
+	s := game.Status{Health: 100, Weapons: 1}
+	f := newFace(s)
+	f.tick(s)
+	test.Assert(strings.HasPrefix(f.name, "STFST0"), "expected straight face, got "+f.name, t)
+
+	s.Health = 90
+	f.tick(s)
+	test.Assert(f.name == "STFKILL0", "expected pain face, got "+f.name, t)
+
+	s.Health = 50
+	f.tick(s)
+	test.Assert(f.name == "STFOUCH2", "expected ouch face, got "+f.name, t)
+
+	s.Weapons = 2
+	f.tick(s)
+	test.Assert(f.name == "STFEVL2", "expected evil grin, got "+f.name, t)
+	for i := 0; i < evilGrinTics; i++ {
+		f.tick(s)
+	}
+	test.Assert(strings.HasPrefix(f.name, "STFST2"), "expected straight face after the grin, got "+f.name, t)
+
+	s.God = true
+	f.tick(s)
+	test.Assert(f.name == "STFGOD0", "expected god face, got "+f.name, t)
+	f.tick(s)
+	test.Assert(f.name == "STFGOD0", "expected god face to stay, got "+f.name, t)
+
+	s.God = false
+	s.Health = 0
+	f.tick(s)
+	f.tick(s)
+	test.Assert(f.name == "STFDEAD0", "expected dead face, got "+f.name, t)
+}
+
+type recorder []string
+
+func (r *recorder) DrawGraphic(name string, x, y float32) {
+	*r = append(*r, name)
+}
+
+func TestDrawKeys(t *testing.T) {
+	var (
+		sb = NewStatusBar(graphics.NewFontBook())
+		s  = game.Status{Health: 100}
+		r  = recorder{}
+	)
+	s.Keys[game.KeyYellowCard] = true
+	s.Keys[game.KeyRedCard] = true
+	s.Keys[game.KeyRedSkull] = true
+	sb.Update(s, 0)
+	sb.Draw(&r, s)
+	drawn := strings.Join(r, " ")
+	test.Assert(strings.HasPrefix(drawn, "STBAR STARMS"), "expected background first, got "+drawn, t)
+	test.Assert(strings.Contains(drawn, "STFST0"), "expected face, got "+drawn, t)
+	test.Assert(strings.Contains(drawn, "STKEYS1 STKEYS5"), "expected yellow card and red skull, got "+drawn, t)
+	test.Assert(!strings.Contains(drawn, "STKEYS2"), "red card must be hidden by the skull key", t)
+}
@@ -0,0 +1,148 @@
+package hud
+
+import (
+	"fmt"
+
+	"github.com/tinogoehlert/goom/game"
+	"github.com/tinogoehlert/goom/graphics"
+)
+
+// positions of the status bar widgets on the 320x200 screen, taken from vanilla
+const (
+	// Height of the status bar
+	Height = 32
+
+	barX, barY       = 0, 200 - Height
+	armsBgX, armsBgY = 104, 168
+	ammoX, ammoY     = 44, 171
+	healthX, healthY = 90, 171
+	armorX, armorY   = 221, 171
+	armsX, armsY     = 111, 172
+	armsXSpace       = 12
+	armsYSpace       = 10
+	faceX, faceY     = 143, 168
+	keysX            = 239
+	ammoTableX       = 288
+	maxAmmoTableX    = 314
+	// minusOffset distance of the minus sign from the digits
+	minusOffset = 8
+)
+
+var (
+	// keysY rows of the blue, yellow and red keys
+	keysY = [3]float32{171, 181, 191}
+	// ammoTableY rows of clips, shells, cells and missiles
+	ammoTableY = [game.NumAmmo]float32{173, 179, 191, 185}
+)
+
+// StatusBar draws the DOOM status bar with the player's health, armor,
+// ammunition, arms, keys and the marine face.
+type StatusBar struct {
+	fonts   graphics.FontBook
+	face    *face
+	lastTic int
+}
+
+// NewStatusBar creates a status bar using the number fonts of the font book.
+func NewStatusBar(fonts graphics.FontBook) *StatusBar {
+	return &StatusBar{fonts: fonts}
+}
+
+// Update advances the face animation to the game tic.
+func (sb *StatusBar) Update(s game.Status, tic int) {
+	if sb.face == nil || tic < sb.lastTic {
+		// first update or a new level
+		sb.face = newFace(s)
+		sb.lastTic = tic - 1
+	}
+	for ; sb.lastTic < tic; sb.lastTic++ {
+		sb.face.tick(s)
+	}
+}
+
+// Draw draws the status bar.
+func (sb *StatusBar) Draw(d graphics.GraphicDrawer, s game.Status) {
+	d.DrawGraphic("STBAR", barX, barY)
+	d.DrawGraphic("STARMS", armsBgX, armsBgY)
+
+	if s.Ammo >= 0 {
+		sb.drawNum(d, graphics.FnNumRedBig, s.Ammo, 3, ammoX, ammoY)
+	}
+	sb.drawPercent(d, s.Health, healthX, healthY)
+	sb.drawPercent(d, s.Armor, armorX, armorY)
+
+	for i, owned := range s.Arms {
+		font := graphics.FnNumGreySmall
+		if owned {
+			font = graphics.FnNumYellowSmall
+		}
+		var (
+			x = float32(armsX + (i%3)*armsXSpace)
+			y = float32(armsY + (i/3)*armsYSpace)
+		)
+		sb.drawGlyph(d, font, rune('2'+i), x, y)
+	}
+
+	if sb.face != nil && sb.face.name != "" {
+		d.DrawGraphic(sb.face.name, faceX, faceY)
+	}
+
+	for i, y := range keysY {
+		switch {
+		case s.Keys[game.KeyBlueSkull+game.Key(i)]:
+			d.DrawGraphic(fmt.Sprintf("STKEYS%d", 3+i), keysX, y)
+		case s.Keys[game.KeyBlueCard+game.Key(i)]:
+			d.DrawGraphic(fmt.Sprintf("STKEYS%d", i), keysX, y)
+		}
+	}
+
+	for i, y := range ammoTableY {
+		sb.drawNum(d, graphics.FnNumYellowSmall, s.AmmoTable[i], 3, ammoTableX, y)
+		sb.drawNum(d, graphics.FnNumYellowSmall, s.MaxAmmo[i], 3, maxAmmoTableX, y)
+	}
+}
+
+// drawPercent draws a number followed by a percent sign at x.
+func (sb *StatusBar) drawPercent(d graphics.GraphicDrawer, value int, x, y float32) {
+	sb.drawNum(d, graphics.FnNumRedBig, value, 3, x, y)
+	sb.drawGlyph(d, graphics.FnNumRedBig, '%', x, y)
+}
+
+// drawNum draws a number right aligned to x with at most digits digits.
+func (sb *StatusBar) drawNum(d graphics.GraphicDrawer, fn graphics.FontName, value, digits int, x, y float32) {
+	w := sb.glyphWidth(fn, '0')
+	if w == 0 {
+		return
+	}
+	negative := value < 0
+	if negative {
+		value = -value
+	}
+	for i := 0; i < digits; i++ {
+		x -= w
+		sb.drawGlyph(d, fn, rune('0'+value%10), x, y)
+		value /= 10
+		if value == 0 {
+			break
+		}
+	}
+	// only the big font has a minus sign
+	if negative && fn == graphics.FnNumRedBig {
+		sb.drawGlyph(d, fn, '-', x-minusOffset, y)
+	}
+}
+
+func (sb *StatusBar) drawGlyph(d graphics.GraphicDrawer, fn graphics.FontName, r rune, x, y float32) {
+	f := sb.fonts[fn]
+	if g := f.GetGlyph(r); g != nil && g.DoomPicture != nil {
+		d.DrawGraphic(g.GetName(), x, y)
+	}
+}
+
+func (sb *StatusBar) glyphWidth(fn graphics.FontName, r rune) float32 {
+	f := sb.fonts[fn]
+	if g := f.GetGlyph(r); g != nil && g.DoomPicture != nil {
+		return float32(g.Width())
+	}
+	return 0
+}
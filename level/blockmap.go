@@ -1,14 +1,76 @@
 package level
 
 import (
+	"encoding/binary"
+	"fmt"
+
 	"github.com/tinogoehlert/goom/utils"
+	"github.com/tinogoehlert/goom/wad"
+)
+
+const (
+	// BlockSize width and height of a block in map units
+	BlockSize = 128
+	// blockmapHeaderSize origin, columns and rows
+	blockmapHeaderSize = 8
+	// blockListEnd terminates the linedefs of a block
+	blockListEnd = 0xFFFF
 )
 
 // BlockList list of LineDefs within the Block
-type BlockList struct {
-}
+type BlockList []int
 
 // BlockMap is simply a grid of "blocks"' each 128×128 units
 type BlockMap struct {
-	Origin utils.Vec2
+	Origin  utils.Vec2
+	Columns int
+	Rows    int
+	blocks  []BlockList
+}
+
+func newBlockMapFromLump(lump *wad.Lump) (*BlockMap, error) {
+	data := lump.Data
+	if len(data) < blockmapHeaderSize {
+		return nil, fmt.Errorf("header too short")
+	}
+	bm := &BlockMap{
+		Origin:  utils.V2(utils.Int16Tof32(data[0:2]), utils.Int16Tof32(data[2:4])),
+		Columns: int(binary.LittleEndian.Uint16(data[4:6])),
+		Rows:    int(binary.LittleEndian.Uint16(data[6:8])),
+	}
+	count := bm.Columns * bm.Rows
+	if len(data) < blockmapHeaderSize+count*2 {
+		return nil, fmt.Errorf("%d blocks but %d bytes", count, len(data))
+	}
+	bm.blocks = make([]BlockList, count)
+	for i := range bm.blocks {
+		// offsets are in 16 bit words, each list starts with a 0
+		offset := int(binary.LittleEndian.Uint16(data[blockmapHeaderSize+i*2:])) * 2
+		for j := offset + 2; j+2 <= len(data); j += 2 {
+			line := binary.LittleEndian.Uint16(data[j:])
+			if line == blockListEnd {
+				break
+			}
+			bm.blocks[i] = append(bm.blocks[i], int(line))
+		}
+	}
+	return bm, nil
+}
+
+// Block gets the linedefs of the block in column col and row row.
+func (bm *BlockMap) Block(col, row int) BlockList {
+	if col < 0 || row < 0 || col >= bm.Columns || row >= bm.Rows {
+		return nil
+	}
+	return bm.blocks[row*bm.Columns+col]
+}
+
+// BlockAt gets the column and row of the block containing x, y.
+func (bm *BlockMap) BlockAt(x, y float32) (col, row int, ok bool) {
+	col = int((x - bm.Origin.X()) / BlockSize)
+	row = int((y - bm.Origin.Y()) / BlockSize)
+	if x < bm.Origin.X() || y < bm.Origin.Y() || col >= bm.Columns || row >= bm.Rows {
+		return col, row, false
+	}
+	return col, row, true
 }
@@ -0,0 +1,39 @@
+package level
+
+import (
+	"testing"
+
+	"github.com/tinogoehlert/goom/test"
+	"github.com/tinogoehlert/goom/wad"
+)
+
+func TestBlockMap(t *testing.T) {
+	// 2x1 blocks at -64, 0, the first holds linedefs 0 and 3, the second none
+	bm, err := newBlockMapFromLump(&wad.Lump{Name: BlockmapName, Data: i16s(
+		-64, 0, 2, 1,
+		6, 10,
+		0, 0, 3, -1,
+		0, -1,
+	)})
+	test.Check(err, t)
+	test.Assert(bm.Columns == 2 && bm.Rows == 1, "expected 2x1 blocks", t)
+	l := bm.Block(0, 0)
+	test.Assert(len(l) == 2 && l[0] == 0 && l[1] == 3, "expected linedefs 0 and 3", t)
+	test.Assert(len(bm.Block(1, 0)) == 0, "expected an empty block", t)
+	test.Assert(bm.Block(2, 0) == nil, "expected no block outside of the grid", t)
+
+	col, row, ok := bm.BlockAt(100, 20)
+	test.Assert(ok && col == 1 && row == 0, "expected the second block", t)
+	_, _, ok = bm.BlockAt(-70, 20)
+	test.Assert(!ok, "expected no block left of the origin", t)
+
+	_, err = newBlockMapFromLump(&wad.Lump{Name: BlockmapName, Data: i16s(0, 0, 2, 2)})
+	test.Assert(err != nil, "expected an error for missing offsets", t)
+}
+
+func TestBrokenBlockMap(t *testing.T) {
+	lumps := append(testClassicBspLumps(), lump(BlockmapName, i16s(0, 0, 2)))
+	l, err := NewLevel(lumps)
+	test.Check(err, t)
+	test.Assert(l.BlockMap == nil, "expected the map loaded without blockmap", t)
+}
@@ -0,0 +1,202 @@
+package level
+
+import (
+	"fmt"
+
+	"github.com/tinogoehlert/goom/utils"
+)
+
+const (
+	// clipEpsilon tolerance for points lying on a partition line
+	clipEpsilon = 0.01
+	// polyMargin extends the root polygon beyond the map bounds
+	polyMargin = 64
+)
+
+// HasGLNodes checks if GL nodes were loaded for the level.
+func (l *Level) HasGLNodes() bool {
+	_, ok := l.nodePool[GLNodesName]
+	return ok
+}
+
+// bspNames gets the names of the node and subsector pools used for traversal.
+// GL nodes are preferred, classic nodes are used if GL nodes are unavailable.
+func (l *Level) bspNames() (nodes, ssects string) {
+	if l.HasGLNodes() {
+		return GLNodesName, GLSsectsName
+	}
+	return NodesName, SSectsName
+}
+
+// BspNodes gets the nodes used for traversal, GL_NODES if present, NODES otherwise.
+func (l *Level) BspNodes() []Node {
+	name, _ := l.bspNames()
+	return l.nodePool[name]
+}
+
+// BspSubSectors gets the subsectors belonging to BspNodes.
+func (l *Level) BspSubSectors() []SubSector {
+	_, name := l.bspNames()
+	return l.ssectPool[name]
+}
+
+// SubSectorIndexAt gets the index in BspSubSectors of the subsector containing x, y.
+func (l *Level) SubSectorIndexAt(x, y float32) (int, error) {
+	var (
+		nodes  = l.BspNodes()
+		ssects = l.BspSubSectors()
+	)
+	if len(nodes) == 0 {
+		if len(ssects) == 0 {
+			return 0, errNoSubSectors
+		}
+		return 0, nil
+	}
+	n := &nodes[len(nodes)-1]
+	for i := 0; i < len(nodes); i++ {
+		child := n.Right
+		if n.OnLeftSide(x, y) {
+			child = n.Left
+		}
+		if child.IsSubSector() {
+			return int(child.Num()), nil
+		}
+		if int(child.Num()) >= len(nodes) {
+			break
+		}
+		n = &nodes[child.Num()]
+	}
+	return 0, fmt.Errorf("could not find subsector at %.0f, %.0f", x, y)
+}
+
+// SubSectorPolygon gets the closed, convex outline of the subsector idx of BspSubSectors.
+// GL subsectors are closed by their segs already, classic subsectors are built
+// by clipping the map bounds against the partition lines leading to them.
+func (l *Level) SubSectorPolygon(idx int) []utils.Vec2 {
+	if l.HasGLNodes() {
+		var (
+			segs = l.ssectPool[GLSsectsName][idx].Segments()
+			poly = make([]utils.Vec2, len(segs))
+		)
+		for i, seg := range segs {
+			poly[i] = l.Vert(seg.StartVert())
+		}
+		return poly
+	}
+	if l.polygons == nil {
+		l.polygons = l.buildPolygons()
+	}
+	if idx < 0 || idx >= len(l.polygons) {
+		return nil
+	}
+	return l.polygons[idx]
+}
+
+// buildPolygons creates the outlines of all classic subsectors.
+func (l *Level) buildPolygons() [][]utils.Vec2 {
+	var (
+		nodes  = l.nodePool[NodesName]
+		ssects = l.ssectPool[SSectsName]
+		polys  = make([][]utils.Vec2, len(ssects))
+		root   = l.boundsPolygon()
+	)
+	if len(nodes) == 0 {
+		if len(ssects) > 0 {
+			polys[0] = l.clipBySegs(root, &ssects[0])
+		}
+		return polys
+	}
+
+	var walk func(child NodeChild, poly []utils.Vec2, depth int)
+	walk = func(child NodeChild, poly []utils.Vec2, depth int) {
+		if child.IsSubSector() {
+			if idx := int(child.Num()); idx < len(ssects) {
+				polys[idx] = l.clipBySegs(poly, &ssects[idx])
+			}
+			return
+		}
+		// a malformed tree must not recurse forever
+		if int(child.Num()) >= len(nodes) || depth > len(nodes) {
+			return
+		}
+		n := &nodes[child.Num()]
+		walk(n.Right, clipPolygon(poly, n.position, n.diagonal, false), depth+1)
+		walk(n.Left, clipPolygon(poly, n.position, n.diagonal, true), depth+1)
+	}
+	walk(NodeChild(len(nodes)-1), root, 0)
+	return polys
+}
+
+// clipBySegs cuts away everything behind the segs of a subsector.
+func (l *Level) clipBySegs(poly []utils.Vec2, ssect *SubSector) []utils.Vec2 {
+	for _, seg := range ssect.Segments() {
+		var (
+			start = l.Vert(seg.StartVert())
+			end   = l.Vert(seg.EndVert())
+		)
+		poly = clipPolygon(poly, start, end.Sub(start), false)
+	}
+	return poly
+}
+
+// boundsPolygon gets a rectangle enclosing all vertices of the map.
+func (l *Level) boundsPolygon() []utils.Vec2 {
+	verts := l.vertexPool[VertsName]
+	if len(verts) == 0 {
+		return nil
+	}
+	var (
+		minX, minY = verts[0].X(), verts[0].Y()
+		maxX, maxY = minX, minY
+	)
+	for _, v := range verts {
+		if v.X() < minX {
+			minX = v.X()
+		}
+		if v.X() > maxX {
+			maxX = v.X()
+		}
+		if v.Y() < minY {
+			minY = v.Y()
+		}
+		if v.Y() > maxY {
+			maxY = v.Y()
+		}
+	}
+	minX, minY, maxX, maxY = minX-polyMargin, minY-polyMargin, maxX+polyMargin, maxY+polyMargin
+	return []utils.Vec2{
+		utils.V2(minX, minY),
+		utils.V2(minX, maxY),
+		utils.V2(maxX, maxY),
+		utils.V2(maxX, minY),
+	}
+}
+
+// clipPolygon clips a convex polygon against the line through p with direction d.
+// The part on the left side of the line is kept if left is set, the right side otherwise.
+func clipPolygon(poly []utils.Vec2, p, d utils.Vec2, left bool) []utils.Vec2 {
+	if len(poly) == 0 || d.Length() == 0 {
+		return poly
+	}
+	side := func(v utils.Vec2) float32 {
+		s := d.Cross(v.Sub(p)) / d.Length()
+		if !left {
+			return -s
+		}
+		return s
+	}
+	out := make([]utils.Vec2, 0, len(poly)+1)
+	for i := range poly {
+		var (
+			a, b   = poly[i], poly[(i+1)%len(poly)]
+			sa, sb = side(a), side(b)
+		)
+		if sa >= -clipEpsilon {
+			out = append(out, a)
+		}
+		if (sa > clipEpsilon && sb < -clipEpsilon) || (sa < -clipEpsilon && sb > clipEpsilon) {
+			out = append(out, a.Add(b.Sub(a).Scale(sa/(sa-sb))))
+		}
+	}
+	return out
+}
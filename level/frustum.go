@@ -0,0 +1,179 @@
+package level
+
+import (
+	"math"
+
+	"github.com/tinogoehlert/goom/utils"
+)
+
+// Frustum is the horizontal view cone of a viewer on the map,
+// given by its origin, view direction and the directions of the left and right edges.
+type Frustum struct {
+	Origin utils.Vec2
+	Dir    utils.Vec2
+	Left   utils.Vec2
+	Right  utils.Vec2
+}
+
+// NewFrustum creates the frustum of a viewer at origin looking into dir.
+// fov is the horizontal field of view in degrees, it must be less than 180.
+func NewFrustum(origin, dir utils.Vec2, fov float32) *Frustum {
+	var (
+		half     = float64(fov) / 2 * math.Pi / 180
+		sin, cos = math.Sincos(half)
+		d        = dir.Normalize()
+	)
+	rotate := func(s, c float64) utils.Vec2 {
+		return utils.V2(
+			d.X()*float32(c)-d.Y()*float32(s),
+			d.X()*float32(s)+d.Y()*float32(c),
+		)
+	}
+	return &Frustum{
+		Origin: origin,
+		Dir:    d,
+		Left:   rotate(sin, cos),
+		Right:  rotate(-sin, cos),
+	}
+}
+
+// PointVisible checks if a position lies within the frustum.
+func (f *Frustum) PointVisible(x, y float32) bool {
+	rel := utils.V2(x-f.Origin.X(), y-f.Origin.Y())
+	return f.Left.Cross(rel) <= 0 && f.Right.Cross(rel) >= 0
+}
+
+// BoxVisible checks if any part of the bounding box may lie within the frustum.
+// The test is conservative, boxes near the edges may be reported visible.
+func (f *Frustum) BoxVisible(b BBox) bool {
+	if b.PosInBox(f.Origin.X(), f.Origin.Y()) {
+		return true
+	}
+	var (
+		corners = [4]utils.Vec2{
+			utils.V2(b.Left()-f.Origin.X(), b.Top()-f.Origin.Y()),
+			utils.V2(b.Right()-f.Origin.X(), b.Top()-f.Origin.Y()),
+			utils.V2(b.Right()-f.Origin.X(), b.Bottom()-f.Origin.Y()),
+			utils.V2(b.Left()-f.Origin.X(), b.Bottom()-f.Origin.Y()),
+		}
+		outLeft, outRight, behind = 0, 0, 0
+	)
+	for _, c := range corners {
+		if f.Dir.Dot(c) < 0 {
+			behind++
+		}
+		if f.Left.Cross(c) > 0 {
+			outLeft++
+		}
+		if f.Right.Cross(c) < 0 {
+			outRight++
+		}
+	}
+	return outLeft < len(corners) && outRight < len(corners) && behind < len(corners)
+}
+
+// WalkBspFrom walks through the node tree front to back as seen from the viewer.
+// Children whose bounding box is outside of frustum are skipped, a nil frustum
+// visits all subsectors.
+func (l *Level) WalkBspFrom(viewX, viewY float32, frustum *Frustum, fn func(index int, n *Node, b BBox)) error {
+	var (
+		nodes  = l.BspNodes()
+		ssects = l.BspSubSectors()
+	)
+	if len(ssects) == 0 {
+		return errNoSubSectors
+	}
+	if len(nodes) == 0 {
+		fn(0, nil, BBox{})
+		return nil
+	}
+
+	var walk func(n *Node, depth int)
+	visit := func(n *Node, child NodeChild, b BBox, depth int) {
+		if frustum != nil && !frustum.BoxVisible(b) {
+			return
+		}
+		if child.IsSubSector() {
+			if int(child.Num()) < len(ssects) {
+				fn(int(child.Num()), n, b)
+			}
+			return
+		}
+		if int(child.Num()) < len(nodes) {
+			walk(&nodes[child.Num()], depth+1)
+		}
+	}
+	walk = func(n *Node, depth int) {
+		// a malformed tree must not recurse forever
+		if depth > len(nodes) {
+			return
+		}
+		if n.OnLeftSide(viewX, viewY) {
+			visit(n, n.Left, n.LeftBBox, depth)
+			visit(n, n.Right, n.RightBBox, depth)
+			return
+		}
+		visit(n, n.Right, n.RightBBox, depth)
+		visit(n, n.Left, n.LeftBBox, depth)
+	}
+	walk(&nodes[len(nodes)-1], 0)
+	return nil
+}
+
+// VisibleSet holds the subsectors visible to a viewer, ordered front to back.
+type VisibleSet struct {
+	Order   []int
+	visible map[int]bool
+}
+
+// Contains checks if the subsector idx is visible.
+func (vs *VisibleSet) Contains(idx int) bool {
+	if vs == nil {
+		return false
+	}
+	return vs.visible[idx]
+}
+
+// Len gets the number of visible subsectors.
+func (vs *VisibleSet) Len() int {
+	if vs == nil {
+		return 0
+	}
+	return len(vs.Order)
+}
+
+// VisibleSubSectors collects the subsectors visited by WalkBspFrom.
+func (l *Level) VisibleSubSectors(viewX, viewY float32, frustum *Frustum) (*VisibleSet, error) {
+	vs := &VisibleSet{visible: make(map[int]bool)}
+	err := l.WalkBspFrom(viewX, viewY, frustum, func(idx int, n *Node, b BBox) {
+		if !vs.visible[idx] {
+			vs.visible[idx] = true
+			vs.Order = append(vs.Order, idx)
+		}
+	})
+	return vs, err
+}
+
+// VisibleSectors gets the indices of the sectors of the visible subsectors, each once.
+func (l *Level) VisibleSectors(vs *VisibleSet) []int {
+	var (
+		ssects  = l.BspSubSectors()
+		seen    = make(map[int]bool)
+		sectors []int
+	)
+	if vs == nil {
+		return nil
+	}
+	for _, idx := range vs.Order {
+		if idx < 0 || idx >= len(ssects) {
+			continue
+		}
+		sector := l.SectorFromSSect(&ssects[idx])
+		if sector == nil || seen[sector.Index()] {
+			continue
+		}
+		seen[sector.Index()] = true
+		sectors = append(sectors, sector.Index())
+	}
+	return sectors
+}
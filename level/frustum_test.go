@@ -0,0 +1,49 @@
+package level
+
+import (
+	"testing"
+
+	"github.com/tinogoehlert/goom/test"
+	"github.com/tinogoehlert/goom/utils"
+)
+
+func TestFrustumBoxVisible(t *testing.T) {
+	f := NewFrustum(utils.V2(0, 0), utils.V2(1, 0), 90)
+	test.Assert(f.PointVisible(100, 10), "point ahead must be visible", t)
+	test.Assert(!f.PointVisible(-100, 0), "point behind must not be visible", t)
+	test.Assert(!f.PointVisible(10, 100), "point left of fov must not be visible", t)
+
+	// top, bottom, left, right
+	test.Assert(f.BoxVisible(BBox{10, -10, 50, 60}), "box ahead must be visible", t)
+	test.Assert(!f.BoxVisible(BBox{10, -10, -60, -50}), "box behind must not be visible", t)
+	test.Assert(!f.BoxVisible(BBox{200, 100, 10, 20}), "box left of fov must not be visible", t)
+	test.Assert(f.BoxVisible(BBox{10, -10, -10, 10}), "box around the viewer must be visible", t)
+}
+
+func TestWalkBspFrom(t *testing.T) {
+	s := NewStore()
+	s.loadLumps(withMarker("E1M1", testClassicBspLumps()...))
+	l := s["E1M1"]
+	if l == nil {
+		t.Fatal("map E1M1 not found")
+	}
+
+	// looking from the left half to the right, both are visible front to back
+	vs, err := l.VisibleSubSectors(16, 32, NewFrustum(utils.V2(16, 32), utils.V2(1, 0), 90))
+	test.Check(err, t)
+	test.Assert(len(vs.Order) == 2 && vs.Order[0] == 1 && vs.Order[1] == 0, "expected subsectors 1, 0", t)
+
+	// looking from the right half to the right, the left half is behind
+	vs, err = l.VisibleSubSectors(48, 32, NewFrustum(utils.V2(48, 32), utils.V2(1, 0), 90))
+	test.Check(err, t)
+	test.Assert(vs.Len() == 1 && vs.Contains(0) && !vs.Contains(1), "expected subsector 0 only", t)
+
+	// without frustum all subsectors are visited
+	vs, err = l.VisibleSubSectors(48, 32, nil)
+	test.Check(err, t)
+	test.Assert(len(vs.Order) == 2 && vs.Order[0] == 0, "expected subsectors 0, 1", t)
+
+	// both halves belong to the room's only sector
+	sectors := l.VisibleSectors(vs)
+	test.Assert(len(sectors) == 1 && sectors[0] == 0, "expected sector 0 once", t)
+}
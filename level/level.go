@@ -3,7 +3,6 @@ package level
 import (
 	"errors"
 	"fmt"
-	"regexp"
 
 	"github.com/tinogoehlert/goom/utils"
 	"github.com/tinogoehlert/goom/wad"
@@ -18,6 +17,8 @@ const (
 	SegsName     = "SEGS"
 )
 
+var errNoSubSectors = fmt.Errorf("could not find %s or %s", GLSsectsName, SSectsName)
+
 // Level - A map in Doom is made up of several lumps,
 // each containing specific data required to construct and execute the map.
 type Level struct {
@@ -27,11 +28,18 @@ type Level struct {
 	Sectors   []Sector
 	SideDefs  []SideDef
 	Walls     []Wall
+	// BlockMap of the linedefs, nil if the map has no BLOCKMAP
+	BlockMap *BlockMap
 	// private pools
 	vertexPool map[string][]utils.Vec2
 	segPool    map[string][]Segment
 	ssectPool  map[string][]SubSector
 	nodePool   map[string][]Node
+	polygons   [][]utils.Vec2
+	listeners  map[int]SectorListener
+	nextID     int
+	// moved sectors since the last SaveSectorHeights
+	moved map[int]bool
 }
 
 // Store stores map of levels
@@ -42,30 +50,59 @@ func NewStore() Store {
 	return make(Store)
 }
 
-// LoadWAD loads wad into store
-func (s Store) LoadWAD(w *wad.WAD) error {
-	var (
-		nameRegex   = regexp.MustCompile(`^E\dM\d|^MAP\d\d`)
-		glNameRegex = regexp.MustCompile(`^GL_E\dM\d|^GL_MAP\d\d`)
-	)
-	for i := 0; i < len(w.Lumps()); i++ {
-		lump := w.Lumps()[i]
+// LoadWAD loads all maps and their GL nodes of a wad into store,
+// maps that can't be read are skipped.
+func (s Store) LoadWAD(w *wad.WAD) {
+	s.loadLumps(w.Lumps())
+}
+
+// loadLumps detects maps by their lump sequence. A map is a marker lump
+// followed by THINGS, LINEDEFS, ... in any order. GL nodes follow a GL_<name>
+// marker or a GL_LEVEL marker holding the name in its data.
+func (s Store) loadLumps(lumps []wad.Lump) {
+	for i := 0; i < len(lumps); i++ {
+		marker := &lumps[i]
 		switch {
-		case nameRegex.Match([]byte(lump.Name)):
-			l, err := NewLevel(w.Lumps()[i+1 : i+9])
+		case isGLMarker(lumps, i):
+			n := lumpSequence(lumps, i, glLumps)
+			if err := s.addGLNodes(glLevelName(marker), lumps[i+1:i+1+n]); err != nil {
+				utils.GoomConsole.Print("%s: %s", marker.Name, err.Error())
+			}
+			i += n
+		case isMapMarker(lumps, i):
+			n := lumpSequence(lumps, i, mapLumps)
+			l, err := NewLevel(lumps[i+1 : i+1+n])
 			if err != nil {
-				fmt.Printf("ERROR %s: %s\n", lump.Name, err.Error())
-				continue
+				utils.GoomConsole.Print("skipping map %s: %s", marker.Name, err.Error())
+			} else {
+				l.Name = marker.Name
+				s[l.Name] = l
 			}
-			l.Name = lump.Name
-			s[l.Name] = l
-			i += 7
-		case glNameRegex.Match([]byte(lump.Name)):
-			appendGLNodes(s[lump.Name[3:]], w.Lumps()[i+1:i+5])
-			i += 3
+			i += n
 		}
 	}
-	return nil
+}
+
+// addGLNodes adds the GL nodes to the map name. Maps with broken GL nodes
+// keep their classic nodes, maps without classic nodes are removed.
+func (s Store) addGLNodes(name string, lumps []wad.Lump) error {
+	l, ok := s[name]
+	if !ok {
+		return fmt.Errorf("could not find map %q for GL nodes", name)
+	}
+	err := l.appendGLNodes(lumps)
+	if err == nil {
+		return nil
+	}
+	delete(l.vertexPool, GLVertsName)
+	delete(l.segPool, GLSegsName)
+	delete(l.ssectPool, GLSsectsName)
+	delete(l.nodePool, GLNodesName)
+	if _, ok := l.nodePool[NodesName]; !ok {
+		delete(s, name)
+		return fmt.Errorf("skipping map %s: %s", name, err.Error())
+	}
+	return fmt.Errorf("%s, using the classic nodes", err.Error())
 }
 
 // NewLevel Loads a level from a list of lumps
@@ -76,66 +113,99 @@ func NewLevel(lumps []wad.Lump) (l *Level, err error) {
 		ssectPool:  make(map[string][]SubSector),
 		nodePool:   make(map[string][]Node),
 	}
-	l.Things, err = loadThingsFromLump(&lumps[0])
+	byName := lumpsByName(lumps)
+	for _, name := range requiredLumps {
+		if _, ok := byName[name]; !ok {
+			return nil, fmt.Errorf("missing %s lump", name)
+		}
+	}
+
+	l.Things, err = loadThingsFromLump(byName[ThingsName])
 	if err != nil {
 		return nil, fmt.Errorf("could not read things from WAD: %s", err.Error())
 	}
-	l.LinesDefs, err = newLinedefsFromLump(&lumps[1])
+	l.LinesDefs, err = newLinedefsFromLump(byName[LinedefsName])
 	if err != nil {
 		return nil, fmt.Errorf("could not read linedefs from WAD: %s", err.Error())
 	}
-	l.SideDefs, err = newSidesDefFromLump(&lumps[2])
+	l.SideDefs, err = newSidesDefFromLump(byName[SidedefsName])
 	if err != nil {
 		return nil, fmt.Errorf("could not read sidedefs from WAD: %s", err.Error())
 	}
-	l.vertexPool[lumps[3].Name], err = newVerticesFromLump(&lumps[3])
+	l.vertexPool[VertsName], err = newVerticesFromLump(byName[VertsName])
 	if err != nil {
 		return nil, fmt.Errorf("could not read vertices from WAD: %s", err.Error())
 	}
-	l.segPool[lumps[4].Name], err = newSegmentsFromLump(&lumps[4])
+	l.Sectors, err = newSectorsFromLump(byName[SectorsName])
 	if err != nil {
-		return nil, fmt.Errorf("could not read segs from WAD: %s", err.Error())
+		return nil, fmt.Errorf("could not read sectors from WAD: %s", err.Error())
+	}
+	for i := range l.Sectors {
+		l.Sectors[i].index, l.Sectors[i].level = i, l
 	}
-	segs := l.segPool[lumps[4].Name]
-	l.ssectPool[lumps[5].Name], err = newSSectsFromLump(&lumps[5], segs)
 
-	if err != nil {
-		return nil, fmt.Errorf("could not read subsectors from WAD: %s", err.Error())
+	// the classic BSP is optional, maps may come with GL nodes only
+	if lump, ok := byName[SegsName]; ok {
+		l.segPool[SegsName], err = newSegmentsFromLump(lump)
+		if err != nil {
+			return nil, fmt.Errorf("could not read segs from WAD: %s", err.Error())
+		}
 	}
-	l.nodePool[lumps[6].Name], err = newNodesFromLump(&lumps[6])
-	if err != nil {
-		return nil, fmt.Errorf("could not read nodes from WAD: %s", err.Error())
+	if lump, ok := byName[SSectsName]; ok {
+		l.ssectPool[SSectsName], err = newSSectsFromLump(lump, l.segPool[SegsName])
+		if err != nil {
+			return nil, fmt.Errorf("could not read subsectors from WAD: %s", err.Error())
+		}
 	}
-	l.Sectors, err = newSectorsFromLump(&lumps[7])
-	if err != nil {
-		return nil, fmt.Errorf("could not read sectors from WAD: %s", err.Error())
+	if lump, ok := byName[NodesName]; ok {
+		l.nodePool[NodesName], err = newNodesFromLump(lump)
+		if err != nil {
+			return nil, fmt.Errorf("could not read nodes from WAD: %s", err.Error())
+		}
 	}
 
-	for _, line := range l.LinesDefs {
-		l.Walls = append(l.Walls, NewWall(&line, l))
+	// the blockmap is only shown by the debug overlay, maps load without it
+	if lump, ok := byName[BlockmapName]; ok {
+		if l.BlockMap, err = newBlockMapFromLump(lump); err != nil {
+			utils.GoomConsole.Print("could not read blockmap from WAD: %s", err.Error())
+		}
+	}
+
+	for i := range l.LinesDefs {
+		line := &l.LinesDefs[i]
+		if int(line.Right) < 0 || int(line.Right) >= len(l.SideDefs) {
+			return nil, fmt.Errorf("linedef %d: invalid right sidedef %d", i, line.Right)
+		}
+		if int(line.Left) >= len(l.SideDefs) {
+			return nil, fmt.Errorf("linedef %d: invalid left sidedef %d", i, line.Left)
+		}
+		l.Walls = append(l.Walls, NewWall(line, l))
 	}
 
 	return l, nil
 }
 
-func appendGLNodes(l *Level, lumps []wad.Lump) (err error) {
-	if l == nil {
-		panic("level not found")
+func (l *Level) appendGLNodes(lumps []wad.Lump) (err error) {
+	byName := lumpsByName(lumps)
+	for _, name := range []string{GLVertsName, GLSegsName, GLSsectsName, GLNodesName} {
+		if _, ok := byName[name]; !ok {
+			return fmt.Errorf("missing %s lump", name)
+		}
 	}
-	l.vertexPool[lumps[0].Name], err = newVerticesFromLump(&lumps[0])
+	l.vertexPool[GLVertsName], err = newVerticesFromLump(byName[GLVertsName])
 	if err != nil {
 		return fmt.Errorf("could not load GL_VERT: %s", err.Error())
 	}
-	l.segPool[lumps[1].Name], err = newGLSegmentsFromLump(&lumps[1])
+	l.segPool[GLSegsName], err = newGLSegmentsFromLump(byName[GLSegsName])
 	if err != nil {
 		return fmt.Errorf("could not load GL_SEGS: %s", err.Error())
 	}
-	segs := l.segPool[lumps[1].Name]
-	l.ssectPool[lumps[2].Name], err = newGLSSectsV5FromLump(&lumps[2], segs)
+	segs := l.segPool[GLSegsName]
+	l.ssectPool[GLSsectsName], err = newGLSSectsV5FromLump(byName[GLSsectsName], segs)
 	if err != nil {
-		return fmt.Errorf("could not load GL_SEGS: %s", err.Error())
+		return fmt.Errorf("could not load GL_SSECT: %s", err.Error())
 	}
-	l.nodePool[lumps[3].Name], err = newGLNodesFromLump(&lumps[3])
+	l.nodePool[GLNodesName], err = newGLNodesFromLump(byName[GLNodesName])
 	if err != nil {
 		return fmt.Errorf("could not read GL_NODES from WAD: %s", err.Error())
 	}
@@ -153,9 +223,9 @@ func (l *Level) buildWalls() error {
 // Vert gets a vert
 func (l *Level) Vert(id uint32) utils.Vec2 {
 	if utils.MagicU32(id).MagicBit() {
-		return l.vertexPool["GL_VERT"][utils.MagicU32(id).Uint32()]
+		return l.vertexPool[GLVertsName][utils.MagicU32(id).Uint32()]
 	}
-	return l.vertexPool["VERTEXES"][id]
+	return l.vertexPool[VertsName][id]
 }
 
 // Segments gets segs (SEGS or GL_SEGS)
@@ -186,25 +256,71 @@ func (l *Level) OtherSide(line *LineDef, seg Segment) *SideDef {
 
 // SectorFromSSect gets the sector from a subsector
 func (l *Level) SectorFromSSect(ssect *SubSector) *Sector {
-	var (
-		fseg   = ssect.Segments()[0]
-		line   = l.LinesDefs[fseg.LineDef()]
-		side   = l.SideDefs[line.Right]
-		sector = l.Sectors[side.Sector]
-	)
+	for _, seg := range ssect.Segments() {
+		// GL minisegs do not belong to a linedef
+		if seg.LineDef() < 0 {
+			continue
+		}
+		var (
+			line = l.LinesDefs[seg.LineDef()]
+			side = line.Right
+		)
+		if seg.Direction() == 1 {
+			side = line.Left
+		}
+		return &l.Sectors[l.SideDefs[side].Sector]
+	}
+	return nil
+}
 
-	if fseg.Direction() == 1 {
-		side = l.SideDefs[line.Left]
-		sector = l.Sectors[side.Sector]
+// OnSectorChange registers a listener called after the heights or the light
+// of a sector changed, remove unregisters it.
+func (l *Level) OnSectorChange(listener SectorListener) (remove func()) {
+	if l.listeners == nil {
+		l.listeners = make(map[int]SectorListener)
+	}
+	id := l.nextID
+	l.nextID++
+	l.listeners[id] = listener
+	return func() {
+		delete(l.listeners, id)
+	}
+}
+
+func (l *Level) sectorChanged(sector int, change SectorChange) {
+	if change&(SectorFloorChanged|SectorCeilingChanged) != 0 {
+		if l.moved == nil {
+			l.moved = make(map[int]bool)
+		}
+		l.moved[sector] = true
+	}
+	for _, listener := range l.listeners {
+		listener(sector, change)
+	}
+}
+
+// SaveSectorHeights keeps the sector heights of a game tic, the renderers
+// interpolate between them and the heights of the next tic.
+func (l *Level) SaveSectorHeights() {
+	for i := range l.moved {
+		l.Sectors[i].saveHeights()
+		delete(l.moved, i)
 	}
-	return &sector
 }
 
 // WalkBsp walks through the node tree
 func (l *Level) WalkBsp(fn func(index int, n *Node, b BBox)) error {
-	nodes, ok := l.nodePool[GLNodesName]
-	if !ok {
-		return fmt.Errorf("could not find %s", GLNodesName)
+	var (
+		nodes  = l.BspNodes()
+		ssects = l.BspSubSectors()
+	)
+	if len(ssects) == 0 {
+		return errNoSubSectors
+	}
+	// a map without nodes consists of a single subsector
+	if len(nodes) == 0 {
+		fn(0, nil, BBox{})
+		return nil
 	}
 	for i := len(nodes) - 1; i >= 0; i-- {
 		if nodes[i].Right.IsSubSector() {
@@ -217,8 +333,12 @@ func (l *Level) WalkBsp(fn func(index int, n *Node, b BBox)) error {
 	return nil
 }
 
-// FindPositionInBsp finds a position in the nodeTree
+// FindPositionInBsp finds a position in the nodeTree.
+// Classic nodes are used if GL nodes are requested but not available.
 func (l *Level) FindPositionInBsp(nodeType string, x, y float32) (*SubSector, error) {
+	if nodeType == GLNodesName && !l.HasGLNodes() {
+		nodeType = NodesName
+	}
 	ssects := l.SubSectors(SSectsName)
 	if nodeType == GLNodesName {
 		ssects = l.SubSectors(GLSsectsName)
@@ -227,9 +347,15 @@ func (l *Level) FindPositionInBsp(nodeType string, x, y float32) (*SubSector, er
 	if !ok {
 		return nil, fmt.Errorf("could not find %s", nodeType)
 	}
+	if len(nodes) == 0 {
+		if len(ssects) == 0 {
+			return nil, fmt.Errorf("no subsectors found")
+		}
+		return &ssects[0], nil
+	}
 	n := nodes[len(nodes)-1]
 	for i := 0; i < len(nodes); i++ {
-		if x*n.direction.X()+y*n.direction.Y() > n.dirDeg {
+		if n.OnLeftSide(x, y) {
 			if n.Left.IsSubSector() {
 				return &ssects[n.Left.Num()], nil
 			}
@@ -0,0 +1,256 @@
+package level
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/tinogoehlert/goom/test"
+	"github.com/tinogoehlert/goom/utils"
+	"github.com/tinogoehlert/goom/wad"
+)
+
+func lump(name string, data []byte) wad.Lump {
+	return wad.Lump{Name: name, Size: len(data), Data: data}
+}
+
+func i16s(values ...int16) []byte {
+	buff := make([]byte, len(values)*2)
+	for i, v := range values {
+		binary.LittleEndian.PutUint16(buff[i*2:], uint16(v))
+	}
+	return buff
+}
+
+// minimal map: a single one-sided line in a single sector
+func testMapLumps() []wad.Lump {
+	sidedef := make([]byte, sidedefSize)
+	copy(sidedef[4:], "-")
+	copy(sidedef[12:], "-")
+	copy(sidedef[20:], "STARTAN3")
+	sector := make([]byte, sectorSize)
+	copy(sector, i16s(0, 128))
+	copy(sector[4:], "FLOOR4_8")
+	copy(sector[12:], "CEIL3_5")
+	copy(sector[20:], i16s(160, 0, 0))
+
+	return []wad.Lump{
+		lump(ThingsName, i16s(32, 32, 90, 1, 7)),
+		lump(LinedefsName, i16s(0, 1, 1, 0, 0, 0, -1)),
+		lump(SidedefsName, sidedef),
+		lump(VertsName, i16s(0, 0, 64, 0)),
+		lump(SectorsName, sector),
+	}
+}
+
+func withMarker(marker string, lumps ...wad.Lump) []wad.Lump {
+	return append([]wad.Lump{lump(marker, nil)}, lumps...)
+}
+
+func TestLoadMapWithArbitraryName(t *testing.T) {
+	s := NewStore()
+	lumps := withMarker("MYLEVEL", testMapLumps()...)
+	lumps = append(lumps, lump("PLAYPAL", make([]byte, 16)))
+	s.loadLumps(lumps)
+
+	l, ok := s["MYLEVEL"]
+	test.Assert(ok, "map MYLEVEL not found", t)
+	test.Assert(len(s) == 1, "unexpected map count", t)
+	if l != nil {
+		test.Assert(len(l.Things) == 1, "expected one thing", t)
+		test.Assert(len(l.Walls) == 1, "expected one wall", t)
+		test.Assert(l.Walls[0].Sectors.Right.CeilHeight() == 128, "wrong ceiling height", t)
+	}
+}
+
+func TestLoadMapWithReorderedLumps(t *testing.T) {
+	var (
+		s     = NewStore()
+		mlump = testMapLumps()
+	)
+	// BEHAVIOR present, BLOCKMAP/REJECT missing and sectors first
+	lumps := withMarker("MAP01",
+		mlump[4], mlump[3], mlump[2], mlump[1], mlump[0],
+		lump(BehaviorName, []byte("ACS\x00")),
+	)
+	s.loadLumps(lumps)
+	_, ok := s["MAP01"]
+	test.Assert(ok, "map MAP01 not found", t)
+}
+
+func TestLoadMapMissingLump(t *testing.T) {
+	s := NewStore()
+	lumps := withMarker("E1M1", testMapLumps()[:4]...)
+	s.loadLumps(lumps)
+	test.Assert(len(s) == 0, "map without SECTORS must be skipped", t)
+}
+
+func TestSkipBrokenMap(t *testing.T) {
+	s := NewStore()
+	lumps := withMarker("E1M1", testMapLumps()[:4]...)
+	lumps = append(lumps, withMarker("E1M2", testMapLumps()...)...)
+	s.loadLumps(lumps)
+	_, ok := s["E1M1"]
+	test.Assert(!ok, "broken map E1M1 must be skipped", t)
+	_, ok = s["E1M2"]
+	test.Assert(ok, "map E1M2 after the broken map not found", t)
+}
+
+func TestGLLevelName(t *testing.T) {
+	marker := lump(glMarkerName, []byte("LEVEL=mylevel\nBUILDER=glBSP 2.24\n"))
+	test.Assert(glLevelName(&marker) == "MYLEVEL", "wrong GL_LEVEL name", t)
+	marker = lump("GL_E1M1", nil)
+	test.Assert(glLevelName(&marker) == "E1M1", "wrong GL marker name", t)
+}
+
+func TestGLNodesWithoutMap(t *testing.T) {
+	s := NewStore()
+	lumps := withMarker("GL_E1M1",
+		lump(GLVertsName, []byte(glMagicV5)),
+		lump(GLSegsName, nil),
+		lump(GLSsectsName, nil),
+		lump(GLNodesName, nil),
+	)
+	s.loadLumps(lumps)
+	test.Assert(len(s) == 0, "GL nodes without map must be skipped", t)
+}
+
+func TestBrokenGLNodesKeepClassicNodes(t *testing.T) {
+	s := NewStore()
+	lumps := withMarker("E1M1", testClassicBspLumps()...)
+	lumps = append(lumps, withMarker("GL_E1M1", lump(GLVertsName, []byte(glMagicV5)))...)
+	s.loadLumps(lumps)
+	l, ok := s["E1M1"]
+	test.Assert(ok, "map with broken GL nodes must keep its classic nodes", t)
+	if l != nil {
+		test.Assert(!l.HasGLNodes(), "broken GL nodes must be dropped", t)
+		test.Assert(len(l.BspNodes()) > 0, "expected the classic nodes", t)
+	}
+}
+
+// square room 64x64 split by a classic node at x=32
+func testClassicBspLumps() []wad.Lump {
+	sidedef := make([]byte, sidedefSize)
+	copy(sidedef[20:], "STARTAN3")
+	sector := make([]byte, sectorSize)
+	copy(sector, i16s(0, 128))
+
+	return []wad.Lump{
+		lump(ThingsName, i16s(48, 32, 90, 1, 7)),
+		lump(LinedefsName, i16s(
+			0, 1, 1, 0, 0, 0, -1,
+			1, 2, 1, 0, 0, 0, -1,
+			2, 3, 1, 0, 0, 0, -1,
+			3, 0, 1, 0, 0, 0, -1,
+		)),
+		lump(SidedefsName, sidedef),
+		lump(VertsName, i16s(0, 0, 0, 64, 64, 64, 64, 0, 32, 64, 32, 0)),
+		lump(SegsName, i16s(
+			4, 2, 0, 1, 0, 32,
+			2, 3, 0, 2, 0, 0,
+			3, 5, 0, 3, 0, 0,
+			0, 1, 0, 0, 0, 0,
+			1, 4, 0, 1, 0, 0,
+			5, 0, 0, 3, 0, 32,
+		)),
+		lump(SSectsName, i16s(3, 0, 3, 3)),
+		lump(NodesName, append(
+			i16s(32, 0, 0, 64, 64, 0, 32, 64, 64, 0, 0, 32),
+			i16s(-32768, 1|-32768)...,
+		)),
+		lump(SectorsName, sector),
+	}
+}
+
+func polygonArea(poly []utils.Vec2) float32 {
+	var area float32
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%len(poly)]
+		area += a.X()*b.Y() - b.X()*a.Y()
+	}
+	if area < 0 {
+		area = -area
+	}
+	return area / 2
+}
+
+func TestClassicNodesFallback(t *testing.T) {
+	s := NewStore()
+	s.loadLumps(withMarker("E1M1", testClassicBspLumps()...))
+	l := s["E1M1"]
+	if l == nil {
+		t.Fatal("map E1M1 not found")
+	}
+	test.Assert(!l.HasGLNodes(), "unexpected GL nodes", t)
+
+	ssect, err := l.FindPositionInBsp(GLNodesName, 48, 32)
+	test.Check(err, t)
+	test.Assert(ssect == &l.BspSubSectors()[0], "expected right subsector", t)
+	ssect, err = l.FindPositionInBsp(GLNodesName, 16, 32)
+	test.Check(err, t)
+	test.Assert(ssect == &l.BspSubSectors()[1], "expected left subsector", t)
+	idx, err := l.SubSectorIndexAt(16, 32)
+	test.Check(err, t)
+	test.Assert(idx == 1, "expected the index of the left subsector", t)
+
+	visited := 0
+	test.Check(l.WalkBsp(func(i int, n *Node, b BBox) { visited++ }), t)
+	test.Assert(visited == 2, "expected two subsectors", t)
+
+	for i := 0; i < 2; i++ {
+		poly := l.SubSectorPolygon(i)
+		test.Assert(len(poly) == 4, fmt.Sprintf("subsector %d: expected 4 corners, got %d", i, len(poly)), t)
+		area := polygonArea(poly)
+		test.Assert(area > 2047 && area < 2049, fmt.Sprintf("subsector %d: wrong area %f", i, area), t)
+	}
+}
+
+func TestSectorChanges(t *testing.T) {
+	s := NewStore()
+	s.loadLumps(withMarker("E1M1", testMapLumps()...))
+	l := s["E1M1"]
+	if l == nil {
+		t.Fatal("map E1M1 not found")
+	}
+	var (
+		changes = []SectorChange{}
+		remove  = l.OnSectorChange(func(sector int, change SectorChange) {
+			test.Assert(sector == 0, "expected the changed sector", t)
+			changes = append(changes, change)
+		})
+		sector = &l.Sectors[0]
+	)
+	sector.SetFloorHeight(16)
+	sector.SetCeilHeight(128)
+	sector.SetCeilHeight(64)
+	sector.SetLightLevel(96)
+	test.Assert(sector.FloorHeight() == 16 && sector.CeilHeight() == 64 && sector.LightLevel() == 96, "expected new heights and light", t)
+	test.Assert(len(changes) == 3, "unchanged values must not notify", t)
+	test.Assert(changes[0] == SectorFloorChanged && changes[1] == SectorCeilingChanged && changes[2] == SectorLightChanged,
+		"unexpected changes", t)
+
+	remove()
+	sector.SetFloorHeight(0)
+	test.Assert(len(changes) == 3, "removed listeners must not be notified", t)
+}
+
+func TestSectorInterpolation(t *testing.T) {
+	s := NewStore()
+	s.loadLumps(withMarker("E1M1", testMapLumps()...))
+	l := s["E1M1"]
+	if l == nil {
+		t.Fatal("map E1M1 not found")
+	}
+	sector := &l.Sectors[0]
+	floor, ceil := sector.FloorHeight(), sector.CeilHeight()
+	test.Assert(!sector.Moving() && sector.LerpFloorHeight(0.5) == floor, "loaded sectors must not move", t)
+
+	sector.SetFloorHeight(floor + 8)
+	test.Assert(sector.Moving(), "expected a moving sector", t)
+	test.Assert(sector.LerpFloorHeight(0) == floor && sector.LerpFloorHeight(0.5) == floor+4 && sector.LerpFloorHeight(1) == floor+8,
+		"expected the floor interpolated between the tics", t)
+	test.Assert(sector.LerpCeilHeight(0.5) == ceil, "expected the ceiling unchanged", t)
+
+	l.SaveSectorHeights()
+	test.Assert(!sector.Moving() && sector.LerpFloorHeight(0) == floor+8, "expected the saved heights of the tic", t)
+}
@@ -12,6 +12,28 @@ const (
 	linedefSize = 14
 )
 
+// LineDef flags
+const (
+	// LineBlocking blocks players and monsters
+	LineBlocking int16 = 1 << iota
+	// LineBlockMonsters blocks monsters
+	LineBlockMonsters
+	// LineTwoSided has sectors on both sides
+	LineTwoSided
+	// LineUpperUnpegged pegs the upper texture to the top of the front ceiling
+	LineUpperUnpegged
+	// LineLowerUnpegged pegs the lower texture to the front ceiling and the middle texture to the floor
+	LineLowerUnpegged
+	// LineSecret shows as a one sided line on the automap
+	LineSecret
+	// LineBlockSound stops the sound propagation
+	LineBlockSound
+	// LineNeverOnMap is never shown on the automap
+	LineNeverOnMap
+	// LineAlwaysOnMap is always shown on the automap
+	LineAlwaysOnMap
+)
+
 // LineDef is what make up the 'shape' (for lack of a better word) of a map.
 type LineDef struct {
 	Start       int16
@@ -23,6 +45,11 @@ type LineDef struct {
 	Left        int16
 }
 
+// HasFlag checks if the flag is set.
+func (l *LineDef) HasFlag(flag int16) bool {
+	return l.Flags&flag != 0
+}
+
 func newLinedefsFromLump(lump *wad.Lump) ([]LineDef, error) {
 	if lump.Size%linedefSize != 0 {
 		return nil, fmt.Errorf("size missmatch")
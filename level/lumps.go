@@ -0,0 +1,109 @@
+package level
+
+import (
+	"strings"
+
+	"github.com/tinogoehlert/goom/wad"
+)
+
+// names of the lumps a map is made of
+const (
+	ThingsName   = "THINGS"
+	LinedefsName = "LINEDEFS"
+	SidedefsName = "SIDEDEFS"
+	VertsName    = "VERTEXES"
+	SectorsName  = "SECTORS"
+	RejectName   = "REJECT"
+	BlockmapName = "BLOCKMAP"
+	BehaviorName = "BEHAVIOR"
+	ScriptsName  = "SCRIPTS"
+	GLVertsName  = "GL_VERT"
+	GLPvsName    = "GL_PVS"
+
+	// glMarkerName is used by glBSP for maps with names longer than 5 characters.
+	glMarkerName = "GL_LEVEL"
+	glPrefix     = "GL_"
+)
+
+var (
+	// mapLumps are all lumps which may follow a map marker, in any order.
+	mapLumps = map[string]bool{
+		ThingsName:   true,
+		LinedefsName: true,
+		SidedefsName: true,
+		VertsName:    true,
+		SegsName:     true,
+		SSectsName:   true,
+		NodesName:    true,
+		SectorsName:  true,
+		RejectName:   true,
+		BlockmapName: true,
+		BehaviorName: true,
+		ScriptsName:  true,
+	}
+	// glLumps are all lumps which may follow a GL marker, in any order.
+	glLumps = map[string]bool{
+		GLVertsName:  true,
+		GLSegsName:   true,
+		GLSsectsName: true,
+		GLNodesName:  true,
+		GLPvsName:    true,
+	}
+	// requiredLumps must be present in every map.
+	requiredLumps = []string{
+		ThingsName,
+		LinedefsName,
+		SidedefsName,
+		VertsName,
+		SectorsName,
+	}
+)
+
+// lumpSequence counts the lumps following index i whose names are in names.
+func lumpSequence(lumps []wad.Lump, i int, names map[string]bool) int {
+	n := 0
+	for j := i + 1; j < len(lumps) && names[lumps[j].Name]; j++ {
+		n++
+	}
+	return n
+}
+
+// isMapMarker checks if the lump at index i starts a map.
+func isMapMarker(lumps []wad.Lump, i int) bool {
+	if mapLumps[lumps[i].Name] || glLumps[lumps[i].Name] {
+		return false
+	}
+	return lumpSequence(lumps, i, mapLumps) > 0
+}
+
+// isGLMarker checks if the lump at index i starts the GL nodes of a map.
+func isGLMarker(lumps []wad.Lump, i int) bool {
+	if !strings.HasPrefix(lumps[i].Name, glPrefix) || glLumps[lumps[i].Name] {
+		return false
+	}
+	return lumpSequence(lumps, i, glLumps) > 0
+}
+
+// glLevelName gets the name of the map a GL marker belongs to.
+// GL_LEVEL markers store the name as LEVEL=<name> in their data.
+func glLevelName(marker *wad.Lump) string {
+	if marker.Name != glMarkerName {
+		return strings.TrimPrefix(marker.Name, glPrefix)
+	}
+	for _, line := range strings.Split(string(marker.Data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "LEVEL=") {
+			return strings.ToUpper(strings.TrimPrefix(line, "LEVEL="))
+		}
+	}
+	return ""
+}
+
+// lumpsByName maps lumps to their names.
+func lumpsByName(lumps []wad.Lump) map[string]*wad.Lump {
+	m := make(map[string]*wad.Lump, len(lumps))
+	for i := range lumps {
+		m[lumps[i].Name] = &lumps[i]
+	}
+	return m
+}
@@ -0,0 +1,157 @@
+package level
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/tinogoehlert/goom/wad"
+)
+
+// names of the map info lumps, UMAPINFO is read after MAPINFO and wins
+const (
+	MapInfoName  = "MAPINFO"
+	UMapInfoName = "UMAPINFO"
+)
+
+// MapProperties holds the properties of a map set by a map info lump.
+type MapProperties struct {
+	Sky string
+}
+
+// MapInfo maps map names to the properties of MAPINFO or UMAPINFO lumps,
+// maps without an entry use the vanilla defaults.
+type MapInfo map[string]MapProperties
+
+// NewMapInfo creates an empty map info
+func NewMapInfo() MapInfo {
+	return make(MapInfo)
+}
+
+// LoadWAD reads the map info lumps of a wad, later wads override earlier ones.
+func (mi MapInfo) LoadWAD(w *wad.WAD) error {
+	for _, name := range []string{MapInfoName, UMapInfoName} {
+		if lump := w.Lump(name); lump != nil {
+			if err := mi.parse(string(lump.Data)); err != nil {
+				return fmt.Errorf("could not read %s: %s", name, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// parse reads the map entries of the Hexen, ZDoom and UMAPINFO formats, e.g.
+//
+//	map MAP01 "Entryway"
+//	sky1 SKY3 0
+//
+//	map MAP01 { skytexture = "SKY3" }
+//
+// A broken map info changes no entries.
+func (mi MapInfo) parse(text string) error {
+	var (
+		tokens  = mapInfoTokens(text)
+		current = ""
+		parsed  = NewMapInfo()
+	)
+	next := func(i int) (string, int) {
+		for i++; i < len(tokens) && tokens[i] == "="; i++ {
+		}
+		if i >= len(tokens) {
+			return "", i
+		}
+		return tokens[i], i
+	}
+	for i := 0; i < len(tokens); i++ {
+		switch strings.ToLower(tokens[i]) {
+		case "map":
+			current, i = next(i)
+			if current == "" {
+				return fmt.Errorf("missing map name")
+			}
+			current = strings.ToUpper(current)
+		case "defaultmap", "adddefaultmap", "cluster", "episode", "clearepisodes":
+			current = ""
+		case "sky1", "skytexture":
+			var sky string
+			sky, i = next(i)
+			if current != "" && sky != "" {
+				p := parsed[current]
+				p.Sky = strings.ToUpper(sky)
+				parsed[current] = p
+			}
+		}
+	}
+	for name, p := range parsed {
+		mi[name] = p
+	}
+	return nil
+}
+
+// mapInfoTokens splits a map info into words, quoted strings and
+// the separators =, {, } and , without comments.
+func mapInfoTokens(text string) []string {
+	var (
+		tokens = []string{}
+		runes  = []rune(text)
+	)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == ';' || (c == '/' && i+1 < len(runes) && runes[i+1] == '/'):
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			for i += 2; i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/'); i++ {
+			}
+			i++
+		case c == '"':
+			start := i + 1
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		case c == '=' || c == '{' || c == '}':
+			tokens = append(tokens, string(c))
+		case c == ',' || unicode.IsSpace(c):
+		default:
+			start := i
+			for i+1 < len(runes) && !strings.ContainsRune(" \t\r\n=,{}\";", runes[i+1]) {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i+1]))
+		}
+	}
+	return tokens
+}
+
+// Sky gets the sky texture of a map, set by the map info or the vanilla default.
+func (mi MapInfo) Sky(mapName string) string {
+	if p, ok := mi[strings.ToUpper(mapName)]; ok && p.Sky != "" {
+		return p.Sky
+	}
+	return DefaultSky(mapName)
+}
+
+// DefaultSky gets the vanilla sky of a map: SKY1-SKY4 by the episode of ExMy
+// maps and SKY1-SKY3 for MAP01-MAP11, MAP12-MAP20 and MAP21 on.
+func DefaultSky(mapName string) string {
+	name := strings.ToUpper(mapName)
+	if len(name) == 4 && name[0] == 'E' && name[2] == 'M' && name[1] >= '1' && name[1] <= '4' {
+		return "SKY" + name[1:2]
+	}
+	if strings.HasPrefix(name, "MAP") {
+		if n, err := strconv.Atoi(name[3:]); err == nil {
+			switch {
+			case n < 12:
+				return "SKY1"
+			case n < 21:
+				return "SKY2"
+			default:
+				return "SKY3"
+			}
+		}
+	}
+	return "SKY1"
+}
@@ -0,0 +1,59 @@
+package level
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tinogoehlert/goom/test"
+)
+
+func TestDefaultSky(t *testing.T) {
+	for name, sky := range map[string]string{
+		"E1M1":  "SKY1",
+		"e2m9":  "SKY2",
+		"E4M2":  "SKY4",
+		"MAP01": "SKY1",
+		"MAP11": "SKY1",
+		"MAP12": "SKY2",
+		"MAP20": "SKY2",
+		"MAP21": "SKY3",
+		"MAP32": "SKY3",
+		"MYMAP": "SKY1",
+	} {
+		test.Assert(DefaultSky(name) == sky, fmt.Sprintf("%s: expected %s, got %s", name, sky, DefaultSky(name)), t)
+	}
+}
+
+func TestMapInfoSky(t *testing.T) {
+	mi := NewMapInfo()
+	test.Check(mi.parse(`
+; Hexen style
+map MAP01 "Entryway"
+sky1 SKY3 0
+music D_RUNNIN
+
+// ZDoom style
+map MAP02 lookup "HUSTR_2" {
+	sky1 = "sky4", 0
+	/* sky1 = "SKY2" */
+}
+defaultmap
+sky1 SKY2
+`), t)
+	test.Check(mi.parse(`map E1M1 { levelname = "Hangar" skytexture = "SKY4" }`), t)
+
+	test.Assert(mi.Sky("MAP01") == "SKY3", "expected the Hexen style sky", t)
+	test.Assert(mi.Sky("MAP02") == "SKY4", "expected the ZDoom style sky", t)
+	test.Assert(mi.Sky("E1M1") == "SKY4", "expected the UMAPINFO sky", t)
+	test.Assert(mi.Sky("MAP15") == "SKY2", "expected the default sky", t)
+	test.Assert(mi.parse("map") != nil, "expected error for a missing map name", t)
+}
+
+func TestBrokenMapInfoKeepsEntries(t *testing.T) {
+	mi := NewMapInfo()
+	test.Check(mi.parse(`map MAP01 { skytexture = "SKY3" }`), t)
+	test.Assert(mi.parse(`map MAP01 { skytexture = "SKY4" } map MAP02 { skytexture = "SKY4" } map`) != nil,
+		"expected error for a missing map name", t)
+	test.Assert(mi.Sky("MAP01") == "SKY3", "expected the sky of the earlier map info", t)
+	test.Assert(mi.Sky("MAP02") == "SKY1", "expected the default sky", t)
+}
@@ -49,7 +49,7 @@ type NodeChild uint32
 
 func nodeChildI16(v int) NodeChild {
 	n := NodeChild(v &^ (1 << 15))
-	if v > 3000 {
+	if v&(1<<15) != 0 {
 		n |= (1 << 31)
 	}
 	return n
@@ -77,6 +77,16 @@ type Node struct {
 	Left      NodeChild
 }
 
+// OnLeftSide checks if the position is on the left side of the partition line.
+func (n *Node) OnLeftSide(x, y float32) bool {
+	return x*n.direction.X()+y*n.direction.Y() > n.dirDeg
+}
+
+// Partition gets the start and the end of the partition line.
+func (n *Node) Partition() (utils.Vec2, utils.Vec2) {
+	return n.position, n.position.Add(n.diagonal)
+}
+
 func newNodesFromLump(lump *wad.Lump) ([]Node, error) {
 	var (
 		nodeCount = len(lump.Data) / nodeSize
@@ -16,7 +16,22 @@ const (
 	glSsectSize = 8
 )
 
+// SectorChange is a bit set of the changed properties of a sector.
+type SectorChange int
+
+// sector changes
+const (
+	SectorFloorChanged SectorChange = 1 << iota
+	SectorCeilingChanged
+	SectorLightChanged
+)
+
+// SectorListener is called after a sector of a level changed.
+type SectorListener func(sector int, change SectorChange)
+
 type Sector struct {
+	index          int
+	level          *Level
 	floorHeight    float32
 	ceilingHeight  float32
 	floorTexture   string
@@ -26,6 +41,9 @@ type Sector struct {
 	// A number that makes the sector a target of the action specified by any linedef with the same tag number.
 	// Used, for example, to alter the sector's ceiling and/or floor height, lighting level or flats.
 	tag int16
+	// heights of the last tic to interpolate with
+	prevFloorHeight   float32
+	prevCeilingHeight float32
 }
 
 // LightLevel the amount of light applied to the sector and it's childs
@@ -49,6 +67,59 @@ func (s *Sector) FloorTexture() string { return s.floorTexture }
 // Tag sector tags for segs
 func (s *Sector) Tag() int16 { return s.tag }
 
+// Index index of the sector in the sectors of its level
+func (s *Sector) Index() int { return s.index }
+
+// SetFloorHeight moves the floor, e.g. of a lift.
+func (s *Sector) SetFloorHeight(height float32) {
+	if s.floorHeight != height {
+		s.floorHeight = height
+		s.changed(SectorFloorChanged)
+	}
+}
+
+// SetCeilHeight moves the ceiling, e.g. of a door or crusher.
+func (s *Sector) SetCeilHeight(height float32) {
+	if s.ceilingHeight != height {
+		s.ceilingHeight = height
+		s.changed(SectorCeilingChanged)
+	}
+}
+
+// SetLightLevel changes the light, e.g. of a flickering light.
+func (s *Sector) SetLightLevel(light float32) {
+	if s.lightLevel != light {
+		s.lightLevel = light
+		s.changed(SectorLightChanged)
+	}
+}
+
+// LerpFloorHeight interpolates the floor height between the last and the current tic.
+func (s *Sector) LerpFloorHeight(frac float32) float32 {
+	return s.prevFloorHeight + (s.floorHeight-s.prevFloorHeight)*frac
+}
+
+// LerpCeilHeight interpolates the ceiling height between the last and the current tic.
+func (s *Sector) LerpCeilHeight(frac float32) float32 {
+	return s.prevCeilingHeight + (s.ceilingHeight-s.prevCeilingHeight)*frac
+}
+
+// Moving checks if the heights changed since the last tic.
+func (s *Sector) Moving() bool {
+	return s.floorHeight != s.prevFloorHeight || s.ceilingHeight != s.prevCeilingHeight
+}
+
+// saveHeights keeps the heights of the tic to interpolate with.
+func (s *Sector) saveHeights() {
+	s.prevFloorHeight, s.prevCeilingHeight = s.floorHeight, s.ceilingHeight
+}
+
+func (s *Sector) changed(change SectorChange) {
+	if s.level != nil {
+		s.level.sectorChanged(s.index, change)
+	}
+}
+
 func newSectorsFromLump(lump *wad.Lump) ([]Sector, error) {
 	if lump.Size%sectorSize != 0 {
 		return nil, fmt.Errorf("size missmatch")
@@ -68,6 +139,7 @@ func newSectorsFromLump(lump *wad.Lump) ([]Sector, error) {
 			sectorType:     utils.I16(b[20:24]),
 			tag:            utils.I16(b[20:26]),
 		}
+		sectors[i].saveHeights()
 	}
 	return sectors, nil
 }
@@ -90,12 +162,11 @@ func newSSectsFromLump(lump *wad.Lump, segs []Segment) ([]SubSector, error) {
 	for i := 0; i < ssectCount; i++ {
 		vb := lump.Data[(i * ssectSize) : (i*ssectSize)+ssectSize]
 		ssect := SubSector{
-			Count:    uint32(int16(binary.LittleEndian.Uint16(vb[0:2]))),
-			firstSeg: uint32(int16(binary.LittleEndian.Uint16(vb[2:4]))),
+			Count:    uint32(binary.LittleEndian.Uint16(vb[0:2])),
+			firstSeg: uint32(binary.LittleEndian.Uint16(vb[2:4])),
 		}
-		if ssect.firstSeg > 1000000 {
-			fmt.Println("skip this..")
-			return nil, fmt.Errorf("out of bound")
+		if ssect.firstSeg+ssect.Count > uint32(len(segs)) {
+			return nil, fmt.Errorf("subsector %d: segs out of range", i)
 		}
 		ssect.segments = segs[ssect.firstSeg : ssect.firstSeg+ssect.Count]
 		subsectors[i] = ssect
@@ -114,6 +185,9 @@ func newGLSSectsV5FromLump(lump *wad.Lump, segs []Segment) ([]SubSector, error)
 			Count:    binary.LittleEndian.Uint32(vb[0:4]),
 			firstSeg: binary.LittleEndian.Uint32(vb[4:8]),
 		}
+		if ssect.firstSeg+ssect.Count > uint32(len(segs)) {
+			return nil, fmt.Errorf("subsector %d: segs out of range", i)
+		}
 		ssect.segments = segs[ssect.firstSeg : ssect.firstSeg+ssect.Count]
 		subsectors[i] = ssect
 	}
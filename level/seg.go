@@ -33,10 +33,10 @@ type ClassicSegment struct {
 }
 
 //StartVert Start Vertex of the Segment
-func (ds *ClassicSegment) StartVert() uint32 { return uint32(ds.Start) }
+func (ds *ClassicSegment) StartVert() uint32 { return uint32(uint16(ds.Start)) }
 
 //EndVert End Vertex of the Segment
-func (ds *ClassicSegment) EndVert() uint32 { return uint32(ds.End) }
+func (ds *ClassicSegment) EndVert() uint32 { return uint32(uint16(ds.End)) }
 
 // LineDef linedef the Segment belongs to
 func (ds *ClassicSegment) LineDef() int16 { return ds.Linedef }
@@ -36,6 +36,42 @@ func (s *SideDef) Lower() string {
 	return strings.ToUpper(s.LowerName.String())
 }
 
+// SidePart selects one of the three textures of a sidedef.
+type SidePart int
+
+// parts of a sidedef
+const (
+	SideUpper SidePart = iota
+	SideMiddle
+	SideLower
+)
+
+// Texture gets the texture of the part.
+func (s *SideDef) Texture(part SidePart) string {
+	switch part {
+	case SideUpper:
+		return s.Upper()
+	case SideLower:
+		return s.Lower()
+	default:
+		return s.Middle()
+	}
+}
+
+// SetTexture replaces the texture of the part, e.g. to flip a switch.
+func (s *SideDef) SetTexture(part SidePart, name string) {
+	var str utils.DoomStr
+	copy(str[:], strings.ToUpper(name))
+	switch part {
+	case SideUpper:
+		s.UpperName = str
+	case SideLower:
+		s.LowerName = str
+	default:
+		s.MiddleName = str
+	}
+}
+
 func newSidesDefFromLump(lump *wad.Lump) ([]SideDef, error) {
 	if lump.Size%sidedefSize != 0 {
 		return nil, fmt.Errorf("size missmatch")
@@ -18,15 +18,10 @@ const (
 
 // NewVerticesFromLump loads vertices from Lump
 func newVerticesFromLump(lump *wad.Lump) ([]utils.Vec2, error) {
-	var verts []utils.Vec2
-	switch string(lump.Data[0:4]) {
-	case glMagicV5:
-		verts = readGLVertsV5(lump.Data[4:])
-	default:
-		verts = readNormalVerts(lump.Data)
+	if len(lump.Data) >= 4 && string(lump.Data[0:4]) == glMagicV5 {
+		return readGLVertsV5(lump.Data[4:]), nil
 	}
-
-	return verts, nil
+	return readNormalVerts(lump.Data), nil
 }
 
 func readNormalVerts(buff []byte) []utils.Vec2 {
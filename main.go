@@ -7,13 +7,12 @@ import (
 	"strings"
 	"time"
 
-	"github.com/go-gl/mathgl/mgl32"
-
+	"github.com/tinogoehlert/goom/debug"
 	"github.com/tinogoehlert/goom/drivers"
-	"github.com/tinogoehlert/goom/drivers/opengl"
 	drvShared "github.com/tinogoehlert/goom/drivers/pkg"
 	"github.com/tinogoehlert/goom/goom"
 	"github.com/tinogoehlert/goom/graphics"
+	"github.com/tinogoehlert/goom/hud"
 	"github.com/tinogoehlert/goom/level"
 	"github.com/tinogoehlert/goom/run"
 	"github.com/tinogoehlert/goom/utils"
@@ -33,12 +32,25 @@ var (
 	levelName    = flag.String("level", "E1M1", "Level to start e.g. E1M1")
 	fpsMax       = flag.Int("fpsmax", 0, "Limit FPS")
 	winDrv       = flag.String("windowdrv", "sdl", "Window and Input driver name")
+	rendererDrv  = flag.String("renderer", drivers.OpenGL, "Renderer name, opengl|software")
 	freeLook     = flag.Bool("freelook", false, "Allow to look up and down")
+	indexedColor = flag.Bool("indexed", false, "Use the COLORMAP for banded DOOM lighting")
+	hiresPack    = flag.String("hires", "", "Directory or PK3 with high resolution PNG replacements of textures, flats and sprites")
+	gamma        = flag.Int("gamma", 0, "Gamma correction level 0-4, F11 cycles through the levels")
+	resolution   = flag.String("resolution", "", "Internal resolution scaled to the window e.g. 320x200, the window size if empty")
+	debugLayers  = flag.String("debug", "all", "Debug overlay layers shown by F9: partitions,subsectors,sector,normals,things,projectiles,blockmap or all")
 	windowHeight = 600
 	windowWidth  = 800
 	gameDefs     = "resources/defs.yaml"
 
 	verticalMouse = false
+	gammaKeyDown  = false
+	// screenshot is requested by F12 and saved after the frame
+	screenshot     = false
+	screenshotDown = false
+	// showOverlay is toggled by F9, - and = zoom the overlay
+	showOverlay    = false
+	overlayKeyDown = false
 )
 
 func main() {
@@ -68,20 +80,42 @@ func main() {
 
 type engine struct {
 	*run.Runner
-	stats *renderStats
+	stats     *renderStats
+	visible   *level.VisibleSet
+	statusBar *hud.StatusBar
+	overlay   *debug.Overlay
 }
 
-func newEngine(drivers *drivers.Drivers) *engine {
+func newEngine(drv *drivers.Drivers) *engine {
 	var err error
 	e := &engine{
-		&run.Runner{Drivers: drivers},
+		&run.Runner{Drivers: drv},
 		&renderStats{lastUpdate: time.Now()},
+		nil,
+		nil,
+		nil,
 	}
 
 	// init all subsystems
 	e.InitWAD(*iwadfile, *pwadfile, gameDefs)
+	if *hiresPack != "" {
+		if err = e.GameData().LoadReplacementPack(*hiresPack); err != nil {
+			logger.Red("failed to load replacement pack %s", err.Error())
+		}
+	}
 	e.InitAudio()
-	err = e.InitRenderer(windowWidth, windowHeight)
+	opts := drivers.RendererOptions{IndexedColor: *indexedColor}
+	if *resolution != "" {
+		if opts.InternalWidth, opts.InternalHeight, err = parseResolution(*resolution); err != nil {
+			logger.Red("ignoring resolution: %s", err.Error())
+		}
+	}
+	err = e.InitRenderer(
+		drivers.RendererDriver(strings.ToLower(*rendererDrv)),
+		windowWidth,
+		windowHeight,
+		opts,
+	)
 	if err != nil {
 		logger.Red("failed to init renderer %s", err.Error())
 	}
@@ -90,13 +124,19 @@ func newEngine(drivers *drivers.Drivers) *engine {
 	mission := e.GameData().Level(strings.ToUpper(*levelName))
 	e.Renderer().LoadLevel(mission, e.GameData())
 	e.World().LoadLevel(mission)
+	e.statusBar = hud.NewStatusBar(e.GameData().Fonts)
+	layers, err := debug.ParseLayers(*debugLayers)
+	if err != nil {
+		logger.Red("showing all debug layers: %s", err.Error())
+		layers = debug.AllLayers
+	}
+	e.overlay = debug.NewOverlay(layers)
 	player := e.World().Me()
 
 	ssect, err := mission.FindPositionInBsp(level.GLNodesName, player.Position()[0], player.Position()[1])
 	if err != nil {
-		logger.Print("could not find GLnode for pos %v", player.Position())
-	} else {
-		sector := mission.SectorFromSSect(ssect)
+		logger.Print("could not find subsector for pos %v: %s", player.Position(), err.Error())
+	} else if sector := mission.SectorFromSSect(ssect); sector != nil {
 		player.SetSector(sector)
 	}
 
@@ -104,36 +144,64 @@ func newEngine(drivers *drivers.Drivers) *engine {
 }
 
 func (e *engine) render(interpolTime float64) {
-	started := e.GetTime()
-	e.Renderer().RenderNewFrame()
+	var (
+		started = e.GetTime()
+		player  = e.World().Me()
+		frac    = float32(interpolTime)
+		// the view direction is not interpolated, turning is applied by the input right away
+		camPos = player.LerpPosition(frac)
+	)
+	e.Renderer().SetInterpolation(frac)
+	e.Renderer().SetCamera(camPos, player.Direction(), player.LerpHeight(frac))
 	e.Renderer().SetViewPort(e.Window().GetSize())
+	// the color tables are bound for the frame by RenderNewFrame
+	e.Renderer().SetPalette(e.World().Me().PaletteEffects().PaletteIndex())
+	e.Renderer().SetGamma(*gamma)
+	e.Renderer().RenderNewFrame()
+	e.Renderer().SetLevelTime(e.World().LevelTime())
 
 	mission := e.World().GetLevel()
 
-	mission.WalkBsp(func(i int, n *level.Node, b level.BBox) {
+	visible, err := mission.VisibleSubSectors(camPos[0], camPos[1], e.Renderer().Frustum())
+	if err != nil {
+		logger.Print("could not walk BSP: %s", err.Error())
+	}
+	e.visible = visible
+	for _, i := range visible.Order {
 		e.Renderer().DrawSubSector(i)
-	})
-
-	player := e.World().Me()
+	}
 
-	e.Renderer().DrawThings(e.World().Things())
+	things, culled := e.World().VisibleThings(visible)
+	e.stats.things, e.stats.culledThings = len(things), culled
+	e.Renderer().DrawThings(things)
 	e.Renderer().DrawHUD(player, interpolTime)
+	status := player.Status()
+	e.statusBar.Update(status, e.World().LevelTime())
+	e.statusBar.Draw(e.Renderer(), status)
 
 	ssect, err := mission.FindPositionInBsp(level.GLNodesName, player.Position()[0], player.Position()[1])
 	if err != nil {
-		logger.Print("could not find GLnode for pos %v", player.Position())
-	} else {
-		var sector = mission.SectorFromSSect(ssect)
+		logger.Print("could not find subsector for pos %v: %s", player.Position(), err.Error())
+	} else if sector := mission.SectorFromSSect(ssect); sector != nil {
 		player.SetSector(sector)
 		player.Lift(sector.FloorHeight())
 	}
-	e.Renderer().Camera().SetCamera(player.Position(), player.Direction(), player.Height())
-	e.Renderer().SetPlayerPosition(mgl32.Vec3{
-		-player.Position()[0],
-		player.Height(),
-		player.Position()[1],
-	})
+	if showOverlay {
+		e.drawOverlay()
+	}
 	e.stats.showStats(e.GameData(), e.Renderer())
+	if err := e.PresentFrame(); err != nil {
+		logger.Print("could not present frame: %s", err.Error())
+	}
+	if screenshot {
+		screenshot = false
+		file := fmt.Sprintf("goom-%s.png", time.Now().Format("20060102-150405"))
+		if err := drivers.SaveScreenshot(e.Renderer(), file); err != nil {
+			logger.Red("%s", err.Error())
+		} else {
+			logger.Green("saved screenshot %s", file)
+		}
+	}
 	e.stats.countedFrames++
 	ft := e.GetTime() - started
 	e.stats.accumulatedTime += time.Duration(ft * float64(time.Second))
@@ -143,29 +211,24 @@ func (e *engine) render(interpolTime float64) {
 	}
 }
 
-// DrawText draws a string on the screen
-func drawText(fonts graphics.FontBook, fontName graphics.FontName, text string, xpos, ypos, scaleFactor float32, gr *opengl.GLRenderer) {
-	font := fonts[fontName]
-	spacing := float32(font.GetSpacing()) * scaleFactor
-
-	// currently, we only know uppercase glyphs
-	text = strings.ToUpper(text)
-
-	for _, r := range text {
-		if r == ' ' {
-			xpos -= spacing
-			continue
-		}
-
-		glyph := font.GetGlyph(r)
-		if glyph == nil {
-			xpos -= spacing
-			continue
-		}
+// drawOverlay draws the debug overlay and where the player is.
+func (e *engine) drawOverlay() {
+	e.overlay.Build(e.World())
+	e.Renderer().DrawOverlay(e.overlay)
+	style := graphics.TextStyle{
+		Fonts:       []graphics.FontName{graphics.FnCompositeRed},
+		LineSpacing: 1,
+	}
+	e.GameData().Fonts.Layout(e.overlay.Info(), style).Draw(e.Renderer(), 2, 2)
+}
 
-		gr.DrawHUdElement(glyph.GetName(), xpos, ypos, scaleFactor)
-		xpos -= spacing + float32(glyph.Width())*scaleFactor
+// parseResolution parses a resolution like 320x200.
+func parseResolution(res string) (int, int, error) {
+	var w, h int
+	if _, err := fmt.Sscanf(res, "%dx%d", &w, &h); err != nil || w <= 0 || h <= 0 {
+		return 0, 0, fmt.Errorf("invalid resolution %s, expected WIDTHxHEIGHT", res)
 	}
+	return w, h, nil
 }
 
 type renderStats struct {
@@ -174,9 +237,12 @@ type renderStats struct {
 	fps             int
 	meanFrameTime   float32
 	lastUpdate      time.Time
+	// things drawn and culled by the visible sectors in the last frame
+	things       int
+	culledThings int
 }
 
-func (rs *renderStats) showStats(gd *goom.GameData, gr *opengl.GLRenderer) {
+func (rs *renderStats) showStats(gd *goom.GameData, gr graphics.GraphicDrawer) {
 	t1 := time.Now()
 	if t1.Sub(rs.lastUpdate) >= time.Second {
 		rs.fps = rs.countedFrames
@@ -186,12 +252,16 @@ func (rs *renderStats) showStats(gd *goom.GameData, gr *opengl.GLRenderer) {
 		rs.lastUpdate = t1
 	}
 
-	fpsText := fmt.Sprintf("FPS: %d", rs.fps)
-	// TODO: position relatively to the window size
-	drawText(gd.Fonts, graphics.FnCompositeRed, fpsText, 800, 600, 0.6, gr)
-	ftimeText := fmt.Sprintf("frame time: %.6f ms", rs.meanFrameTime)
-	// TODO: position relatively to the window size
-	drawText(gd.Fonts, graphics.FnCompositeRed, ftimeText, 800, 580, 0.6, gr)
+	var (
+		style = graphics.TextStyle{
+			Fonts:       []graphics.FontName{graphics.FnCompositeRed},
+			Align:       graphics.AlignRight,
+			LineSpacing: 1,
+		}
+		text = fmt.Sprintf("FPS: %d\nframe time: %.6f ms\nthings: %d culled: %d", rs.fps, rs.meanFrameTime, rs.things, rs.culledThings)
+		tl   = gd.Fonts.Layout(text, style)
+	)
+	tl.Draw(gr, drvShared.ScreenWidth-float32(tl.Width)-2, 2)
 }
 
 func input(e *engine) {
@@ -245,6 +315,39 @@ func input(e *engine) {
 		in.SetMouseCameraEnabled(false)
 	}
 
+	if in.IsPressed(drvShared.KeyF11) {
+		if !gammaKeyDown {
+			*gamma = (*gamma + 1) % graphics.NumGammaLevels
+		}
+		gammaKeyDown = true
+	} else {
+		gammaKeyDown = false
+	}
+
+	if in.IsPressed(drvShared.KeyF12) {
+		screenshot = screenshot || !screenshotDown
+		screenshotDown = true
+	} else {
+		screenshotDown = false
+	}
+
+	if in.IsPressed(drvShared.KeyF9) {
+		if !overlayKeyDown {
+			showOverlay = !showOverlay
+		}
+		overlayKeyDown = true
+	} else {
+		overlayKeyDown = false
+	}
+	if showOverlay {
+		if in.IsPressed(drvShared.KeyMinus) {
+			e.overlay.Zoom(1.02)
+		}
+		if in.IsPressed(drvShared.KeyEquals) {
+			e.overlay.Zoom(1 / 1.02)
+		}
+	}
+
 	if in.IsPressed(drvShared.KeyF7) {
 		verticalMouse = true
 	}
@@ -3,14 +3,98 @@
 uniform sampler2D tex;
 
 in vec2 fragTexCoord;
-flat in vec2 v_r;
-in vec4 v_p;
 in float dist;
 out vec4 outColor;
 
 uniform float sectorLight;
 uniform int draw_phase;
 
+// indexed color: textures hold palette indices, 255 is transparent
+uniform int indexed;
+uniform int fixed_colormap;
+uniform sampler2D palette;
+uniform sampler2D colormap;
+
+// palette effects of true color: blend with the tint of the effect palette
+// and apply the gamma exponent, indexed color has both in the palette
+uniform vec4 tint;
+uniform float gamma;
+
+// sky cylinder of the software renderer: screen center x, horizon y, focal length
+// and yaw in pixels and radians, the sky texture size, the viewport height and
+// the horizontal focal length, which differs for pixels stretched over the window
+uniform vec4 sky_view;
+uniform vec4 sky_size;
+
+// render mode of sprites, same as game.RenderStyle: 0 opaque texels,
+// 1 translucent and 2 fuzz of partial invisibility, which changes every tic
+uniform int render_mode;
+uniform float translucency;
+uniform float fuzz_seed;
+// graphics.FuzzOffsets, 0 for the row above and 1 below
+uniform sampler2D fuzz_offsets;
+
+// same as the software renderer's skyColumns, skyTextureMid and skyFocal
+const float skyColumns = 1024.0;
+const float skyTextureMid = 100.0;
+const float skyFocal = 160.0;
+
+// same as graphics.LightIndex and graphics.HUDLightIndex
+int lightIndex(float light, float d) {
+    int start = (15 - clamp(int(light) / 16, 0, 15)) * 4;
+    if (draw_phase == 2) {
+      return clamp(start - 23, 0, 31);
+    }
+    int z = clamp(int(d) / 16, 0, 127);
+    return clamp(start - (160 / (z + 1)) / 2, 0, 31);
+}
+
+vec4 indexedColor(vec2 uv, int map) {
+    int idx = int(texture(tex, uv).r * 255.0 + 0.5);
+    if (idx == 255) {
+      discard;
+    }
+    int mapped = int(texelFetch(colormap, ivec2(idx, map), 0).r * 255.0 + 0.5);
+    return texelFetch(palette, ivec2(mapped, 0), 0);
+}
+
+
+// skyCoord gets the sky texture coordinates of a pixel, the columns
+// follow the view angle and the rows the height above the horizon
+vec2 skyCoord(vec2 fragCoord) {
+    vec2 p = vec2(fragCoord.x, sky_size.z - fragCoord.y);
+    float angle = sky_view.w - atan((p.x - sky_view.x) / sky_size.w);
+    float col = angle / (2.0 * 3.14159265358) * skyColumns;
+    float row = skyTextureMid + (p.y - sky_view.y) * skyFocal / sky_view.z;
+    return vec2(col / sky_size.x, row / sky_size.y);
+}
+
+// fuzzOffset walks the fuzz offsets along the columns of the original
+// resolution like the software renderer, starting at the seed
+float fuzzOffset(vec2 fragCoord) {
+    ivec2 p = ivec2(fragCoord / 2.0);
+    int size = textureSize(fuzz_offsets, 0).x;
+    int rows = int(sky_size.z / 2.0); // the viewport height
+    int pos = (p.x * rows + p.y + int(fuzz_seed)) % size;
+    return texelFetch(fuzz_offsets, ivec2(pos, 0), 0).r;
+}
+
+// masked blends an opaque texel of a sprite with what is behind it, fuzzy
+// sprites darken the pixels behind their shape in a flickering pattern
+vec4 masked(vec4 color) {
+    if (render_mode == 1) {
+      return vec4(color.rgb, translucency);
+    }
+    if (render_mode == 2) {
+      return vec4(vec3(0.0), fuzzOffset(gl_FragCoord.xy) < 0.5 ? 0.15 : 0.5);
+    }
+    return color;
+}
+
+vec4 effects(vec4 color) {
+    vec3 rgb = mix(color.rgb, tint.rgb, tint.a);
+    return vec4(pow(rgb, vec3(gamma)), color.a);
+}
 
 // TODO: use this in combination with the distance.
 vec3 saturation(vec3 rgb, float adjustment)
@@ -24,10 +108,17 @@ vec3 saturation(vec3 rgb, float adjustment)
 void main()
 {
     if (draw_phase == 3) {
-      vec2 uv = vec2(v_p.x, v_p.y) / v_p.w/1.6 * vec2(1, -1);
-      uv = vec2(uv.x - 2.0 * v_r.x / 3.14159265358, uv.y + v_r.y);
-      uv -= 0.3;
-      outColor = texture(tex, uv);
+      vec2 uv = skyCoord(gl_FragCoord.xy);
+      if (indexed == 1) {
+        outColor = indexedColor(uv, 0);
+        return;
+      }
+      outColor = effects(texture(tex, uv));
+      return;
+    }
+    if (indexed == 1) {
+      int map = fixed_colormap >= 0 ? fixed_colormap : lightIndex(sectorLight, dist);
+      outColor = masked(indexedColor(fragTexCoord, map));
       return;
     }
     float alpha = texture(tex, fragTexCoord).a;
@@ -43,6 +134,7 @@ void main()
       if (draw_phase != 2 && sectorLight < 160) {
         outColor.rgb = saturation(outColor.rgb,1.0 - clamp(dist,0,1000)/1000).rgb;
       }
+      outColor = masked(effects(outColor));
     } else {
       discard;
     }
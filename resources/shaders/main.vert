@@ -14,24 +14,13 @@ uniform vec3 player_pos;
 uniform int billboard_flipped;
 uniform vec3 billboard_pos;
 uniform vec2 billboard_size;
+// offset and size of the sprite in its texture, which may be an atlas page
+uniform vec4 uv_rect;
 
 out vec2 fragTexCoord;
 out float light;
-flat out vec2 v_r;
-out vec4 v_p;
 out float dist;
 
-
-vec4 drawSky() {
-	mat4 transform = projection * model * view;
-    vec4 forward = transform[2];
-    v_r = vec2(atan(forward.x, forward.z), 1);
-	vec4 projected_pos = transform * vec4(vertex, 1);
-    v_p = projected_pos;
-	
-    return projected_pos;
-}
-
 vec4 drawBillboard() {
 	vec3 particleCenter_wordspace = billboard_pos;
 	vec3 CameraRight_worldspace = vec3(view[0][0], view[1][0], view[2][0]);
@@ -44,6 +33,10 @@ vec4 drawBillboard() {
 	return projection*view * vec4(vertexPosition_worldspace, 1.0f);
 }
 
+vec2 atlasCoord(vec2 uv) {
+	return uv_rect.xy + uv * uv_rect.zw;
+}
+
 vec4 DrawHUD() {
 	vec3 v = vertex;
 	v.x *= billboard_size.x;
@@ -58,22 +51,20 @@ void main()
 	// things code
 	if (draw_phase == 1) {
 		if (billboard_flipped == 1) {
-			fragTexCoord.x = -fragTexCoord.x;
+			fragTexCoord.x = 1.0 - fragTexCoord.x;
 		}
+		fragTexCoord = atlasCoord(fragTexCoord);
 		gl_Position = drawBillboard();	
 		dist = abs(distance(player_pos,billboard_pos));
 		return;
 	}
 	// hud code
 	if (draw_phase == 2) {
-		fragTexCoord.x = -fragTexCoord.x;
+		fragTexCoord.x = 1.0 - fragTexCoord.x;
+		fragTexCoord = atlasCoord(fragTexCoord);
 		gl_Position = DrawHUD();	
 		return;
 	} 
-	if (draw_phase == 3) {
-		gl_Position = drawSky();
-		return;
-	}
 	dist = abs(distance(player_pos,vertex));
 	gl_Position = projection * view  * model * vec4(vertex, 1.0);
 }
@@ -5,7 +5,6 @@ import (
 	"path"
 
 	"github.com/tinogoehlert/goom/drivers"
-	"github.com/tinogoehlert/goom/drivers/opengl"
 	"github.com/tinogoehlert/goom/game"
 	"github.com/tinogoehlert/goom/goom"
 	"github.com/tinogoehlert/goom/utils"
@@ -17,7 +16,9 @@ type Runner struct {
 	gameData *goom.GameData
 	world    *game.World
 	gameDir  string
-	renderer *opengl.GLRenderer
+	renderer drivers.Renderer
+	// offscreen renderers do not show their frames
+	offscreen bool
 }
 
 var logger = utils.GoomConsole
@@ -49,8 +50,8 @@ func (r *Runner) World() *game.World {
 	return r.world
 }
 
-// Renderer returns the game world.
-func (r *Runner) Renderer() *opengl.GLRenderer {
+// Renderer returns the game renderer.
+func (r *Runner) Renderer() drivers.Renderer {
 	if r == nil {
 		return nil
 	}
@@ -93,34 +94,56 @@ func (r *Runner) InitAudio() {
 	logger.Green("Using Music Driver: %T", r.world.Music)
 }
 
-// InitRenderer starts the window driver and GL renderer.
-func (r *Runner) InitRenderer(w, h int) error {
-	var err error
-
-	if err = r.Window().Open("GOOM", w, h); err != nil {
-		return err
+// InitRenderer opens the window and creates the renderer of the driver.
+// Headless runners without window can use the noop or the software renderer,
+// GPU renderers render headless with the Offscreen option.
+func (r *Runner) InitRenderer(name drivers.RendererDriver, w, h int, opts drivers.RendererOptions) error {
+	factory, ok := drivers.RendererDrivers[name]
+	if !ok {
+		return fmt.Errorf("unknown renderer %s", name)
 	}
 
-	if err = opengl.Init(); err != nil {
-		return err
+	// software renderers need no window to render offscreen
+	if r.Window() != nil && !(opts.Offscreen && factory.Software) {
+		if presenter, ok := r.Window().(drivers.FramePresenter); ok {
+			presenter.SetSoftware(factory.Software)
+		} else if factory.Software {
+			return fmt.Errorf("window driver %T can not show frames of the %s renderer", r.Window(), name)
+		}
+		if offscreen, ok := r.Window().(drivers.OffscreenWindow); ok {
+			offscreen.SetOffscreen(opts.Offscreen)
+		} else if opts.Offscreen {
+			return fmt.Errorf("window driver %T can not render offscreen", r.Window())
+		}
+		if err := r.Window().Open("GOOM", w, h); err != nil {
+			return err
+		}
 	}
 
-	r.renderer, err = opengl.NewRenderer(r.gameData)
+	opts.Width, opts.Height = w, h
+	renderer, err := factory.New(r.gameData, opts)
 	if err != nil {
 		return err
 	}
+	r.renderer = renderer
+	r.offscreen = opts.Offscreen
+	return nil
+}
 
-	if err = r.renderer.LoadShaderProgram(
-		"main",
-		path.Join("resources", "shaders", "main.vert"),
-		path.Join("resources", "shaders", "main.frag"),
-	); err != nil {
-		return fmt.Errorf("failed to load shaders: %w", err)
+// PresentFrame finishes the frame and shows the frame of a software renderer
+// in the window, GL renderers scale their frame onto the window.
+func (r *Runner) PresentFrame() error {
+	r.renderer.FinishFrame()
+	if r.offscreen {
+		return nil
 	}
-
-	if err = r.renderer.SetShaderProgram("main"); err != nil {
-		return fmt.Errorf("failed to init shaders: %w", err)
+	fr, ok := r.renderer.(drivers.FrameRenderer)
+	if !ok {
+		return nil
 	}
-
-	return nil
+	presenter, ok := r.Window().(drivers.FramePresenter)
+	if !ok {
+		return nil
+	}
+	return presenter.PresentFrame(fr.Frame())
 }
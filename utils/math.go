@@ -9,3 +9,19 @@ func Clamp(num, min, max float32) float32 {
 	}
 	return num
 }
+
+// Min gets the smaller number.
+func Min(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Max gets the larger number.
+func Max(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
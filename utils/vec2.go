@@ -33,6 +33,11 @@ func (a *Vec2) XY() [2]float32 {
 	return [2]float32{a.x, a.y}
 }
 
+// Length of the vector.
+func (a Vec2) Length() float32 {
+	return a.length
+}
+
 // SetLength Set the length of the vector. A negative [value] will change the vectors
 // orientation and a [value] of zero will set the vector to zero.
 func (a *Vec2) SetLength(length float32) {